@@ -0,0 +1,405 @@
+package hc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttrKind is the type an attribute value is coerced to by AttrType.
+type AttrKind int
+
+const (
+	String AttrKind = iota
+	Int
+	Float
+	Bool
+	// JSON decodes a JSON array, object or scalar, so options='["a","b"]'
+	// can be ranged over.
+	JSON
+	// Duration parses a time.Duration such as "300ms".
+	Duration
+)
+
+var attrKinds = map[string]AttrKind{
+	"string":   String,
+	"int":      Int,
+	"float":    Float,
+	"bool":     Bool,
+	"json":     JSON,
+	"duration": Duration,
+}
+
+func (k AttrKind) String() string {
+	switch k {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case JSON:
+		return "JSON"
+	case Duration:
+		return "duration"
+	}
+	return "string"
+}
+
+// attrCheck validates one attribute value and returns it, possibly converted.
+type attrCheck func(value any) (any, error)
+
+// attrGroup is a set of attributes that may not appear together (exclusive)
+// or must appear together.
+type attrGroup struct {
+	names     []string
+	exclusive bool
+}
+
+func (p *attrPolicy) addCheck(name string, check attrCheck) {
+	key := strings.ToLower(name)
+	if key == "" {
+		return
+	}
+	if p.allowed == nil {
+		p.allowed = make(map[string]struct{})
+	}
+	if p.checks == nil {
+		p.checks = make(map[string][]attrCheck)
+	}
+	p.allowed[key] = struct{}{}
+	p.checks[key] = append(p.checks[key], check)
+}
+
+// AttrType coerces the attribute to kind, so templates receive an int,
+// float64, bool, decoded JSON or time.Duration in .Props instead of a
+// string. Components can declare the same with @props count:int.
+func AttrType(name string, kind AttrKind) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		policy.addCheck(name, func(value any) (any, error) {
+			return coerceAttr(value, kind)
+		})
+		describeAttr(&policy.kinds, name, kind)
+	}
+}
+
+// AttrEnum restricts the attribute to one of values.
+func AttrEnum(name string, values ...string) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		policy.addCheck(name, func(value any) (any, error) {
+			if slices.Contains(values, fmt.Sprint(value)) {
+				return value, nil
+			}
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = strconv.Quote(v)
+			}
+			return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(quoted, ", "), fmt.Sprint(value))
+		})
+		describeAttr(&policy.enums, name, slices.Clone(values))
+	}
+}
+
+// AttrPattern requires the attribute to match re. Anchor the expression to
+// match the whole value.
+func AttrPattern(name string, re *regexp.Regexp) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		policy.addCheck(name, func(value any) (any, error) {
+			if re.MatchString(fmt.Sprint(value)) {
+				return value, nil
+			}
+			return nil, fmt.Errorf("must match %s, got %q", re, fmt.Sprint(value))
+		})
+		describeAttr(&policy.patterns, name, re.String())
+	}
+}
+
+// describeAttr records a rule's parameter under the attribute's key, for
+// Components.
+func describeAttr[V any](rules *map[string]V, name string, value V) {
+	key := strings.ToLower(name)
+	if key == "" {
+		return
+	}
+	if *rules == nil {
+		*rules = make(map[string]V)
+	}
+	(*rules)[key] = value
+}
+
+// AttrValidator runs fn on the attribute's value, after any AttrType
+// coercion registered before it.
+func AttrValidator(name string, fn func(value any) error) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		policy.addCheck(name, func(value any) (any, error) {
+			if err := fn(value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		})
+	}
+}
+
+// AttrDefault supplies value in .Props when the caller omits the attribute.
+// Defaults are not checked by the attribute's other rules.
+func AttrDefault(name string, value any) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		key := strings.ToLower(name)
+		if key == "" {
+			return
+		}
+		if policy.defaults == nil {
+			policy.defaults = make(map[string]any)
+		}
+		if policy.allowed == nil {
+			policy.allowed = make(map[string]struct{})
+		}
+		policy.defaults[key] = value
+		policy.allowed[key] = struct{}{}
+	}
+}
+
+// AttrExclusive lets callers pass at most one of names.
+func AttrExclusive(names ...string) AttrRuleOption {
+	return attrGroupRule(names, true)
+}
+
+// AttrTogether requires callers to pass all of names or none of them.
+func AttrTogether(names ...string) AttrRuleOption {
+	return attrGroupRule(names, false)
+}
+
+func attrGroupRule(names []string, exclusive bool) AttrRuleOption {
+	return func(policy *attrPolicy) {
+		group := attrGroup{exclusive: exclusive}
+		for _, name := range names {
+			if key := strings.ToLower(name); key != "" {
+				group.names = append(group.names, key)
+			}
+		}
+		if len(group.names) < 2 {
+			return
+		}
+		if policy.allowed == nil {
+			policy.allowed = make(map[string]struct{})
+		}
+		for _, key := range group.names {
+			policy.allowed[key] = struct{}{}
+		}
+		policy.groups = append(policy.groups, group)
+	}
+}
+
+// checkGroups returns a description of the first group whose rule present
+// breaks, or "".
+func (p attrPolicy) checkGroups(present func(string) bool) string {
+	for _, group := range p.groups {
+		var have, lack []string
+		for _, name := range group.names {
+			if present(name) {
+				have = append(have, name)
+			} else {
+				lack = append(lack, name)
+			}
+		}
+		switch {
+		case group.exclusive && len(have) > 1:
+			return fmt.Sprintf("attrs %s are mutually exclusive", quoteNames(have))
+		case !group.exclusive && len(have) > 0 && len(lack) > 0:
+			return fmt.Sprintf("attr %q requires %s", have[0], quoteNames(lack))
+		}
+	}
+	return ""
+}
+
+// checkValues runs the value rules over props in name order, storing the
+// converted values back.
+func (p attrPolicy) checkValues(props map[string]any) (string, error) {
+	for _, name := range slices.Sorted(maps.Keys(p.checks)) {
+		value, ok := props[name]
+		if !ok {
+			continue
+		}
+		for _, check := range p.checks[name] {
+			var err error
+			if value, err = check(value); err != nil {
+				return name, err
+			}
+		}
+		props[name] = value
+	}
+	return "", nil
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+var errNotNumber = errors.New("not a number")
+
+func coerceAttr(value any, kind AttrKind) (any, error) {
+	switch kind {
+	case String:
+		return fmt.Sprint(value), nil
+	case Bool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("must be a bool, got %q", v)
+			}
+			return b, nil
+		}
+	case Int:
+		if s, ok := value.(string); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("must be an int, got %q", s)
+			}
+			return n, nil
+		}
+		if f, err := numberValue(value); err == nil && f == math.Trunc(f) {
+			return int(f), nil
+		}
+	case Float:
+		if s, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be a float, got %q", s)
+			}
+			return f, nil
+		}
+		if f, err := numberValue(value); err == nil {
+			return f, nil
+		}
+	case JSON:
+		s, ok := value.(string)
+		if !ok {
+			// Values passed from Go are already decoded.
+			return value, nil
+		}
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("must be JSON, got %q: %v", s, err)
+		}
+		return v, nil
+	case Duration:
+		switch v := value.(type) {
+		case time.Duration:
+			return v, nil
+		case string:
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("must be a duration, got %q", v)
+			}
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("must be %s, got %T", kindArticle(kind), value)
+}
+
+// kindArticle names kind with its indefinite article, as in "an int".
+func kindArticle(kind AttrKind) string {
+	switch kind {
+	case Int:
+		return "an int"
+	case JSON:
+		return "JSON"
+	}
+	return "a " + kind.String()
+}
+
+// numberValue converts Go numeric values, such as props passed from Go or
+// decoded from JSON.
+func numberValue(value any) (float64, error) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return 0, errNotNumber
+}
+
+// WithInferredAttrs interprets the attribute values of the named components,
+// or of every component when none are named, the way JSON would: integers
+// become int, decimals float64, and values starting with "[" or "{" are
+// decoded. Numbers with leading zeros, such as "007", stay strings. Values
+// that attribute rules type explicitly are left to those rules.
+func WithInferredAttrs(components ...string) Option {
+	return func(h *HC) {
+		if len(components) == 0 {
+			h.cfg.inferAllAttrs = true
+			return
+		}
+		if h.cfg.inferAttrs == nil {
+			h.cfg.inferAttrs = make(map[string]struct{})
+		}
+		for _, name := range components {
+			if name = strings.TrimSpace(name); name != "" {
+				h.cfg.inferAttrs[strings.ToLower(name)] = struct{}{}
+			}
+		}
+	}
+}
+
+// plainNumber matches the numbers inferAttrValue converts.
+var plainNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// inferAttrs replaces the string props of component with inferred values,
+// in both props and resolved.
+func (h *HC) inferAttrs(component string, props map[string]any, resolved []resolvedAttr) {
+	if !h.cfg.inferAllAttrs {
+		if _, ok := h.cfg.inferAttrs[strings.ToLower(component)]; !ok {
+			return
+		}
+	}
+	policy, _ := h.attrPolicy(component)
+	for i, attr := range resolved {
+		if _, typed := policy.checks[attr.Canonical]; typed {
+			continue
+		}
+		if s, ok := attr.Value.(string); ok {
+			resolved[i].Value = inferAttrValue(s)
+			props[attr.Canonical] = resolved[i].Value
+		}
+	}
+}
+
+func inferAttrValue(s string) any {
+	trimmed := strings.TrimSpace(s)
+	if m := plainNumber.FindStringSubmatch(trimmed); m != nil {
+		if m[2] == "" {
+			if n, err := strconv.Atoi(trimmed); err == nil {
+				return n
+			}
+		}
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return f
+		}
+	}
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			return v
+		}
+	}
+	return s
+}
@@ -0,0 +1,278 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/esrid/hc/internal/yaml"
+)
+
+// Route is one page of a static build.
+type Route struct {
+	// Path is the URL the page is served at, such as "/" or "/pricing".
+	// Paths without an extension are written as <path>/index.html so they
+	// work as clean URLs; paths such as "/404.html" are written as is.
+	Path string
+	// Page is the page template to render.
+	Page string
+	// Data is passed to the page when neither Loader nor Fixture is set.
+	Data any
+	// Loader produces the page data at build time.
+	Loader func(context.Context) (any, error)
+	// Fixture is a JSON (.json) or YAML (.yaml, .yml) file whose contents
+	// become the page data when Loader is not set.
+	Fixture string
+}
+
+type BuildOption func(*buildConfig)
+
+type buildConfig struct {
+	assets      []assetDir
+	baseURL     string
+	concurrency int
+}
+
+type assetDir struct {
+	src, dst string
+}
+
+// BuildAssets copies the directory src into dst, relative to the output
+// directory, after the pages are rendered.
+func BuildAssets(src, dst string) BuildOption {
+	return func(cfg *buildConfig) {
+		cfg.assets = append(cfg.assets, assetDir{src: src, dst: dst})
+	}
+}
+
+// BuildBaseURL sets the site origin used for the absolute URLs in
+// sitemap.xml, such as "https://example.com". Sitemaps require absolute
+// URLs, so without it no sitemap is written.
+func BuildBaseURL(url string) BuildOption {
+	return func(cfg *buildConfig) {
+		cfg.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// BuildConcurrency sets how many pages render at once. It defaults to
+// GOMAXPROCS.
+func BuildConcurrency(n int) BuildOption {
+	return func(cfg *buildConfig) {
+		if n > 0 {
+			cfg.concurrency = n
+		}
+	}
+}
+
+// Build renders every route into outDir, copies static assets and, when
+// BuildBaseURL is set, writes a sitemap.xml listing the clean-URL pages.
+// Routes written as is, such as "/404.html", are left out of it. Pages
+// render in parallel and share the engine's component cache. Every failing
+// route is reported, not only the first.
+func (h *HC) Build(ctx context.Context, outDir string, routes []Route, opts ...BuildOption) error {
+	cfg := buildConfig{concurrency: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	targets := make([]string, len(routes))
+	owners := make(map[string]string, len(routes))
+	for i, route := range routes {
+		if route.Page == "" {
+			return fmt.Errorf("build %s: route has no page", route.Path)
+		}
+		target, err := routeFile(route.Path)
+		if err != nil {
+			return err
+		}
+		if other, dup := owners[target]; dup {
+			return fmt.Errorf("build %s: routes %s and %s both write %s", route.Path, other, route.Path, target)
+		}
+		owners[target] = route.Path
+		targets[i] = target
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	errs := make([]error, len(routes))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, route := range routes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := h.buildRoute(ctx, route, filepath.Join(outDir, filepath.FromSlash(targets[i]))); err != nil {
+				errs[i] = fmt.Errorf("build %s: %w", route.Path, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, asset := range cfg.assets {
+		if err := h.copyAssets(asset, outDir); err != nil {
+			return fmt.Errorf("copy assets %s: %w", asset.src, err)
+		}
+	}
+
+	if cfg.baseURL == "" {
+		return nil
+	}
+	return writeSitemap(filepath.Join(outDir, "sitemap.xml"), cfg.baseURL, routes)
+}
+
+func (h *HC) buildRoute(ctx context.Context, route Route, target string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := h.routeData(ctx, route)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := h.ParseFileContext(ctx, &buf, route.Page, data); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, buf.Bytes(), 0o644)
+}
+
+func (h *HC) routeData(ctx context.Context, route Route) (any, error) {
+	switch {
+	case route.Loader != nil:
+		return route.Loader(ctx)
+	case route.Fixture != "":
+		content, err := h.readFile(route.Fixture)
+		if err != nil {
+			return nil, err
+		}
+		return decodeFixture(route.Fixture, content)
+	}
+	return route.Data, nil
+}
+
+func decodeFixture(name string, content []byte) (any, error) {
+	var (
+		data any
+		err  error
+	)
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".json":
+		err = json.Unmarshal(content, &data)
+	case ".yaml", ".yml":
+		data, err = yaml.Unmarshal(content)
+	default:
+		return nil, fmt.Errorf("fixture %s: unsupported format %q", name, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// routeFile returns the slash-separated file a route path is written to.
+func routeFile(urlPath string) (string, error) {
+	if urlPath == "" {
+		return "", errors.New("build: route has no path")
+	}
+	clean := path.Clean("/" + urlPath)
+	if path.Ext(clean) != "" {
+		return clean[1:], nil
+	}
+	return path.Join(clean[1:], "index.html"), nil
+}
+
+// routeURL returns the canonical URL path of a route.
+func routeURL(urlPath string) string {
+	clean := path.Clean("/" + urlPath)
+	if clean == "/" || path.Ext(clean) != "" {
+		return clean
+	}
+	return clean + "/"
+}
+
+func (h *HC) copyAssets(asset assetDir, outDir string) error {
+	var (
+		fsys fs.FS
+		root = "."
+	)
+	if h.cfg.fs != nil {
+		fsys, root = h.cfg.fs, asset.src
+	} else {
+		fsys = os.DirFS(asset.src)
+	}
+
+	dst := filepath.Join(outDir, filepath.FromSlash(asset.dst))
+	return fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := p
+		switch {
+		case p == root:
+			rel = ""
+		case root != ".":
+			rel = strings.TrimPrefix(p, root+"/")
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0o644)
+	})
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+func writeSitemap(target, baseURL string, routes []Route) error {
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, route := range routes {
+		url := routeURL(route.Path)
+		if path.Ext(url) != "" {
+			continue
+		}
+		set.URLs = append(set.URLs, sitemapURL{Loc: baseURL + url})
+	}
+	slices.SortFunc(set.URLs, func(a, b sitemapURL) int {
+		return strings.Compare(a.Loc, b.Loc)
+	})
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, append([]byte(xml.Header), append(out, '\n')...), 0o644)
+}
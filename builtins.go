@@ -0,0 +1,397 @@
+package hc
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	texttmpl "text/template"
+
+	"github.com/esrid/hc/internal/markup"
+)
+
+// exprCaptureFunc is the helper evaluateExpr wraps expressions in to hand the
+// raw pipeline value back to Go instead of its printed form; see
+// HC.captureExprValue.
+const exprCaptureFunc = "hcValue"
+
+type builtinComponent func(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error
+
+// builtinComponents are evaluated by the renderer itself. A component file
+// with the same name wins, so an existing switch.html keeps rendering; see
+// HC.builtin.
+var builtinComponents map[string]builtinComponent
+
+func init() {
+	builtinComponents = map[string]builtinComponent{
+		"If":      renderIf,
+		"ElseIf":  renderElseIf,
+		"Else":    renderElse,
+		"For":     renderFor,
+		"Switch":  renderSwitch,
+		"Case":    renderStrayCase,
+		"Default": renderStrayCase,
+	}
+}
+
+// builtin returns the built-in called name, unless a component file of that
+// name exists. The answer is cached like component sources are.
+func (h *HC) builtin(name string) (builtinComponent, bool) {
+	fn, ok := builtinComponents[name]
+	if !ok {
+		return nil, false
+	}
+	key := strings.ToLower(name)
+	h.cache.mu.RLock()
+	shadowed, known := h.cache.shadowedBuiltins[key]
+	h.cache.mu.RUnlock()
+	if !known {
+		_, err := h.lookupComponentSource(name)
+		shadowed = err == nil
+		h.cache.mu.Lock()
+		if h.cache.shadowedBuiltins == nil {
+			h.cache.shadowedBuiltins = make(map[string]bool)
+		}
+		h.cache.shadowedBuiltins[key] = shadowed
+		h.cache.mu.Unlock()
+	}
+	return fn, !shadowed
+}
+
+// branchState records the outcome of the most recent <If>, <ElseIf> or <For>
+// in a markup run so a following <ElseIf> or <Else> knows whether to render.
+type branchState struct {
+	pending bool
+	taken   bool
+}
+
+func (b *branchState) reset() {
+	b.pending = false
+	b.taken = false
+}
+
+func renderIf(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	ok, err := h.evaluateCond(state, tag)
+	if err != nil {
+		return err
+	}
+	branch.pending = true
+	branch.taken = ok
+	if !ok {
+		return nil
+	}
+	return h.renderBuiltinChildren(state, tag, writer)
+}
+
+func renderElseIf(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	if !branch.pending {
+		return errors.New("<ElseIf> must follow <If> or <ElseIf>")
+	}
+	if branch.taken {
+		return nil
+	}
+	ok, err := h.evaluateCond(state, tag)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	branch.taken = true
+	return h.renderBuiltinChildren(state, tag, writer)
+}
+
+func renderElse(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	if !branch.pending {
+		return errors.New("<Else> must follow <If>, <ElseIf> or <For>")
+	}
+	taken := branch.taken
+	branch.reset()
+	if taken {
+		return nil
+	}
+	return h.renderBuiltinChildren(state, tag, writer)
+}
+
+func renderFor(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	each, ok := builtinAttr(tag, "each")
+	if !ok {
+		return errors.New(`<For> requires an "each" attribute`)
+	}
+	collection, err := h.evaluateExpr(state, each)
+	if err != nil {
+		return fmt.Errorf("<For> each: %w", err)
+	}
+
+	itemName := "item"
+	if name, ok := builtinAttr(tag, "as"); ok && strings.TrimSpace(name) != "" {
+		itemName = strings.TrimSpace(name)
+	}
+	indexName, _ := builtinAttr(tag, "index")
+	indexName = strings.TrimSpace(indexName)
+
+	body := state.withSource(tag.ChildrenOrigin)
+	iterations := 0
+	err = rangeValue(collection, func(key, value any) error {
+		iterations++
+		vars := map[string]any{itemName: value}
+		if indexName != "" {
+			vars[indexName] = key
+		}
+		return h.renderMarkupStream(body.withLocals(vars), tag.Children, writer)
+	})
+	if err != nil {
+		return err
+	}
+
+	branch.pending = true
+	branch.taken = iterations > 0
+	return nil
+}
+
+func renderSwitch(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	branch.reset()
+
+	var subject any
+	expr, hasSubject := builtinAttr(tag, "value")
+	if hasSubject {
+		var err error
+		if subject, err = h.evaluateExpr(state, expr); err != nil {
+			return fmt.Errorf("<Switch> value: %w", err)
+		}
+	}
+
+	body := state.withSource(tag.ChildrenOrigin)
+	positions := markup.NewPositioner(tag.Children)
+	var (
+		matched, fallback *componentTag
+	)
+	err := walkMarkup(tag.Children,
+		func(text []byte) error {
+			return nil
+		},
+		func(caseTag componentTag) error {
+			name := caseTag.Name
+			if name != "Case" && name != "Default" {
+				return fmt.Errorf("<Switch> only accepts <Case> and <Default> children, got <%s>", name)
+			}
+			caseTag.Origin = body.source.advance(positions.At(caseTag.Offset))
+			caseTag.ChildrenOrigin = body.source.advance(positions.At(caseTag.ChildrenOffset))
+			if name == "Default" {
+				fallback = &caseTag
+				return nil
+			}
+			if matched != nil {
+				return nil
+			}
+			ok, err := h.matchCase(body, caseTag, subject, hasSubject)
+			if err != nil {
+				return wrapComponentError(err, ComponentFrame{Component: name, Origin: caseTag.Origin}, nil)
+			}
+			if ok {
+				matched = &caseTag
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if matched == nil {
+		matched = fallback
+	}
+	if matched == nil {
+		return nil
+	}
+	return h.renderBuiltinChildren(state, *matched, writer)
+}
+
+func renderStrayCase(h *HC, state *renderState, tag componentTag, writer io.Writer, branch *branchState) error {
+	return fmt.Errorf("<%s> must be a direct child of <Switch>", tag.Name)
+}
+
+// matchCase reports whether a <Case> applies. Cases compare their value
+// attribute with the switch subject, or evaluate cond when the switch has none.
+func (h *HC) matchCase(state *renderState, tag componentTag, subject any, hasSubject bool) (bool, error) {
+	if cond, ok := builtinAttr(tag, "cond"); ok {
+		value, err := h.evaluateExpr(state, cond)
+		if err != nil {
+			return false, fmt.Errorf("<Case> cond: %w", err)
+		}
+		truth, _ := texttmpl.IsTrue(value)
+		return truth, nil
+	}
+	if !hasSubject {
+		return false, errors.New(`<Case> requires a "cond" attribute when <Switch> has no value`)
+	}
+	raw, ok := builtinAttr(tag, "value")
+	if !ok {
+		return false, errors.New(`<Case> requires a "value" or "cond" attribute`)
+	}
+	value, err := h.evaluateAttr(state, raw)
+	if err != nil {
+		return false, fmt.Errorf("<Case> value: %w", err)
+	}
+	return fmt.Sprint(value) == fmt.Sprint(subject), nil
+}
+
+func (h *HC) evaluateCond(state *renderState, tag componentTag) (bool, error) {
+	cond, ok := builtinAttr(tag, "cond")
+	if !ok {
+		return false, fmt.Errorf(`<%s> requires a "cond" attribute`, tag.Name)
+	}
+	value, err := h.evaluateExpr(state, cond)
+	if err != nil {
+		return false, fmt.Errorf("<%s> cond: %w", tag.Name, err)
+	}
+	truth, _ := texttmpl.IsTrue(value)
+	return truth, nil
+}
+
+func (h *HC) renderBuiltinChildren(state *renderState, tag componentTag, writer io.Writer) error {
+	if len(tag.Children) == 0 {
+		return nil
+	}
+	return h.renderMarkupStream(state.withSource(tag.ChildrenOrigin), tag.Children, writer)
+}
+
+// evaluateExpr evaluates a built-in attribute as a template pipeline and
+// returns its value rather than its text. Both ".Items" and "{{ .Items }}"
+// are accepted; values mixing text and actions evaluate to a string.
+func (h *HC) evaluateExpr(state *renderState, raw string) (any, error) {
+	expr := strings.TrimSpace(raw)
+	if expr == "" {
+		return nil, nil
+	}
+	d := h.cfg.delims
+	if inner, ok := singleAction(expr, d); ok {
+		expr = inner
+	} else if strings.Contains(expr, d.left) {
+		return h.evaluateAttr(state, raw)
+	}
+
+	tpl, err := h.cachedTextTemplate(state, "\x00expr:"+expr, func() (*texttmpl.Template, error) {
+		funcs := make(template.FuncMap, len(state.funcs)+1)
+		for name, fn := range state.funcs {
+			funcs[name] = fn
+		}
+		funcs[exprCaptureFunc] = h.captureExprValue
+		return parseAttrTemplate(d.left+" "+exprCaptureFunc+" ("+expr+") "+d.right, funcs, d, h.strictKeys(""))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := state.scopeData(tpl)
+	if err != nil {
+		return nil, err
+	}
+	var id strings.Builder
+	if err := tpl.Execute(&id, scope); err != nil {
+		return nil, err
+	}
+	value, _ := h.exprValues.LoadAndDelete(id.String())
+	return value, nil
+}
+
+// captureExprValue stores the value of an expression evaluateExpr runs and
+// prints the ID it is stored under. The shared template then needs no
+// per-call state, so it is executed without cloning it first.
+func (h *HC) captureExprValue(v any) string {
+	id := strconv.FormatUint(h.exprIDs.Add(1), 36)
+	h.exprValues.Store(id, v)
+	return id
+}
+
+// singleAction returns the pipeline inside expr when expr is exactly one
+// {{ ... }} action.
+func singleAction(expr string, d delims) (string, bool) {
+	if len(expr) < len(d.left)+len(d.right) || !strings.HasPrefix(expr, d.left) || !strings.HasSuffix(expr, d.right) {
+		return "", false
+	}
+	inner := expr[len(d.left) : len(expr)-len(d.right)]
+	if strings.Contains(inner, d.left) || strings.Contains(inner, d.right) {
+		return "", false
+	}
+	inner = strings.TrimPrefix(inner, "-")
+	inner = strings.TrimSuffix(inner, "-")
+	return strings.TrimSpace(inner), true
+}
+
+func builtinAttr(tag componentTag, name string) (string, bool) {
+	for _, attr := range tag.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// rangeValue iterates slices, arrays, maps (in sorted key order, like
+// text/template) and integers, calling fn with each key and value.
+func rangeValue(collection any, fn func(key, value any) error) error {
+	if collection == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(collection)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := fn(i, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		for _, key := range keys {
+			if err := fn(key.Interface(), rv.MapIndex(key).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		for i := int64(0); i < rv.Int(); i++ {
+			if err := fn(int(i), int(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if rv.Len() == 0 {
+			return nil
+		}
+		return fmt.Errorf("cannot range over string %q", rv.String())
+	default:
+		return fmt.Errorf("cannot range over %T", collection)
+	}
+	return nil
+}
+
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() != b.Kind() {
+		return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	}
+	switch a.Kind() {
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+	return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/esrid/hc"
+)
+
+func build(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	components := flags.String("components", "web/components", "component `folder`")
+	pages := flags.String("pages", "web/pages", "page `folder`; each page becomes a route named after its path")
+	fixtures := flags.String("fixtures", "", "`folder` of JSON or YAML page data, mirroring the page folder")
+	out := flags.String("out", "dist", "output `folder`")
+	baseURL := flags.String("base-url", "", "site `origin` for sitemap.xml, such as https://example.com; no sitemap is written without it")
+	jobs := flags.Int("j", 0, "pages to render at once (default GOMAXPROCS)")
+	var opts []hc.BuildOption
+	flags.Func("assets", "copy `dir[=dest]` into the output folder (repeatable; dest defaults to the folder name)", func(value string) error {
+		src, dst, ok := strings.Cut(value, "=")
+		if !ok {
+			dst = filepath.Base(src)
+		}
+		opts = append(opts, hc.BuildAssets(src, dst))
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hc build [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	routes, err := pageRoutes(*pages, *fixtures)
+	if err != nil {
+		fmt.Fprintf(stderr, "hc build: %v\n", err)
+		return 2
+	}
+	opts = append(opts, hc.BuildBaseURL(*baseURL), hc.BuildConcurrency(*jobs))
+
+	// Fixture data is meant for the page's own actions, which only run in
+	// the final template pass.
+	engine := hc.NewHC(*components, hc.WithFinalTemplatePass())
+	if err := engine.Build(context.Background(), *out, routes, opts...); err != nil {
+		fmt.Fprintf(stderr, "hc build: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "built %d pages into %s\n", len(routes), *out)
+	return 0
+}
+
+// pageRoutes turns every page below dir into a route: pages/about.gohtml is
+// served at /about and pages/blog/index.gohtml at /blog. A fixture with the
+// same relative path and a .json, .yaml or .yml extension supplies its data.
+func pageRoutes(dir, fixtures string) ([]hc.Route, error) {
+	var routes []hc.Route
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isTemplateFile(p) {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = strings.TrimSuffix(filepath.ToSlash(rel), path.Ext(rel))
+
+		urlPath := "/" + rel
+		if rel == "index" || strings.HasSuffix(rel, "/index") {
+			urlPath = "/" + strings.TrimSuffix(strings.TrimSuffix(rel, "index"), "/")
+		}
+		route := hc.Route{Page: p, Path: urlPath}
+		if fixtures != "" {
+			for _, ext := range []string{".json", ".yaml", ".yml"} {
+				candidate := filepath.Join(fixtures, filepath.FromSlash(rel)+ext)
+				if _, err := os.Stat(candidate); err == nil {
+					route.Fixture = candidate
+					break
+				}
+			}
+		}
+		routes = append(routes, route)
+		return nil
+	})
+	return routes, err
+}
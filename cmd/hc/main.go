@@ -0,0 +1,128 @@
+// Command hc works with hc component trees from the command line.
+//
+// Usage:
+//
+//	hc lint [-components dir] [page or directory ...]
+//	hc build [-components dir] [-pages dir] [-fixtures dir] [-assets dir] [-out dir]
+//
+// lint checks pages and components without rendering them and prints one
+// file:line:column diagnostic per problem. It exits with status 1 when it
+// reports anything, and 2 when it cannot run.
+//
+// build renders every page into a static site with clean URLs, copies
+// assets and writes sitemap.xml.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/esrid/hc"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "lint":
+		return lint(args[1:], stdout, stderr)
+	case "build":
+		return build(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "hc: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: hc <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  build   render pages into a static site")
+	fmt.Fprintln(w, "  lint    check pages and components without rendering them")
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	components := flags.String("components", "web/components", "component `folder`")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hc lint [-components dir] [page or directory ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	pages, err := expandPages(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "hc lint: %v\n", err)
+		return 2
+	}
+
+	// The application's helpers are not available here, so template
+	// functions are not checked.
+	engine := hc.NewHC(*components)
+	diags, err := engine.Lint(context.Background(), pages, hc.LintSkipFuncCheck())
+	if err != nil {
+		fmt.Fprintf(stderr, "hc lint: %v\n", err)
+		return 2
+	}
+	for _, diag := range diags {
+		fmt.Fprintln(stdout, diag)
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// expandPages replaces directories with the template files below them.
+func expandPages(args []string) ([]string, error) {
+	var pages []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			pages = append(pages, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isTemplateFile(p) {
+				pages = append(pages, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+func isTemplateFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".gohtml", ".tmpl", ".html":
+		return true
+	}
+	return false
+}
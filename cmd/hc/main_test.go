@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint_ExitStatus(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	components := filepath.Join(tmp, "components")
+	pages := filepath.Join(tmp, "pages")
+	for _, dir := range []string{components, pages} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(components, "button.html"), []byte(`<button>{{ t .Props.label }}</button>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	page := filepath.Join(pages, "home.gohtml")
+	if err := os.WriteFile(page, []byte(`<Button label="ok" />`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lint", "-components", components, pages}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected clean lint, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	if err := os.WriteFile(page, []byte("<Button label=\"ok\" />\n<Buton />"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{"lint", "-components", components, pages}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit status 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), page+":2:1: error: unknown component Buton") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestPageRoutes(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	for _, name := range []string{"pages/index.gohtml", "pages/about.html", "pages/blog/index.gohtml", "pages/blog/reindex.gohtml", "fixtures/about.yaml"} {
+		full := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	routes, err := pageRoutes(filepath.Join(tmp, "pages"), filepath.Join(tmp, "fixtures"))
+	if err != nil {
+		t.Fatalf("pageRoutes: %v", err)
+	}
+	var got []string
+	for _, route := range routes {
+		entry := route.Path
+		if route.Fixture != "" {
+			entry += " " + filepath.Base(route.Fixture)
+		}
+		got = append(got, entry)
+	}
+	want := "/about about.yaml,/blog,/blog/reindex,/"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("routes = %v, want %s", got, want)
+	}
+}
+
+func TestBuild_RendersFixtureData(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	files := map[string]string{
+		"components/card.html": `<section>{{ .Props.title }}{{ .Children }}</section>`,
+		"pages/about.gohtml":   `<h1>{{ .Title }}</h1><Card title="{{ .Title }}"><p>{{ .Body }}</p></Card>`,
+		"fixtures/about.yaml":  "Title: About us\nBody: Hello\n",
+		"pages/index.gohtml":   `<p>home</p>`,
+	}
+	for name, contents := range files {
+		full := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(tmp, "dist")
+	var stdout, stderr bytes.Buffer
+	args := []string{"build", "-components", filepath.Join(tmp, "components"), "-pages", filepath.Join(tmp, "pages"), "-fixtures", filepath.Join(tmp, "fixtures"), "-out", out}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("build exited %d: %s", code, stderr.String())
+	}
+
+	got, err := os.ReadFile(filepath.Join(out, "about", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<h1>About us</h1><section>About us<p>Hello</p></section>`; string(got) != want {
+		t.Fatalf("about page = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package hc
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ComponentInfo describes a template file in the component folder.
+type ComponentInfo struct {
+	// Name is the tag that invokes the component, derived from the file name.
+	Name     string
+	Source   string
+	Template string
+	// Rules holds the attributes registered with WithAttrRules or declared
+	// with @props, or nil when the component accepts anything.
+	Rules *AttrRules
+}
+
+// AttrRules lists the attributes a component accepts, in name order.
+type AttrRules struct {
+	Required []string
+	// Allowed includes the required attributes.
+	Allowed     []string
+	AllowOthers bool
+	// Types, Enums, Patterns and Defaults hold the AttrType, AttrEnum,
+	// AttrPattern and AttrDefault rules by attribute name. Validators
+	// registered with AttrValidator cannot be described and are left out.
+	Types    map[string]AttrKind
+	Enums    map[string][]string
+	Patterns map[string]string
+	Defaults map[string]any
+	// Exclusive and Together list the AttrExclusive and AttrTogether groups.
+	Exclusive [][]string
+	Together  [][]string
+}
+
+// Components lists the components in the component folder, in name order.
+func (h *HC) Components() ([]ComponentInfo, error) {
+	files, err := h.componentFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ComponentInfo, 0, len(files))
+	for _, file := range files {
+		content, err := h.readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		info := ComponentInfo{
+			Name:     componentNameFromFile(file),
+			Source:   file,
+			Template: string(content),
+		}
+		policy, ok := h.cfg.attrPolicies[strings.ToLower(info.Name)]
+		if !ok {
+			if decl := parseComponentDecl(content, h.componentDelims(info.Name)); decl.props != nil {
+				policy, ok = *decl.props, true
+			}
+		}
+		if ok {
+			info.Rules = policy.describe()
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b ComponentInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return infos, nil
+}
+
+// describe copies the policy's rules into an AttrRules.
+func (p attrPolicy) describe() *AttrRules {
+	rules := &AttrRules{
+		Required:    slices.Sorted(maps.Keys(p.required)),
+		Allowed:     slices.Sorted(maps.Keys(p.allowed)),
+		AllowOthers: p.allowOthers,
+		Types:       maps.Clone(p.kinds),
+		Enums:       maps.Clone(p.enums),
+		Patterns:    maps.Clone(p.patterns),
+		Defaults:    maps.Clone(p.defaults),
+	}
+	for _, group := range p.groups {
+		if group.exclusive {
+			rules.Exclusive = append(rules.Exclusive, slices.Clone(group.names))
+		} else {
+			rules.Together = append(rules.Together, slices.Clone(group.names))
+		}
+	}
+	return rules
+}
+
+// ReadFile reads name from the filesystem the engine renders from.
+func (h *HC) ReadFile(name string) ([]byte, error) {
+	return h.readFile(name)
+}
+
+// componentFiles lists the template files directly inside the component folder.
+func (h *HC) componentFiles() ([]string, error) {
+	var (
+		entries []fs.DirEntry
+		err     error
+	)
+	if h.cfg.fs != nil {
+		dir := h.folder
+		if dir == "" {
+			dir = "."
+		}
+		entries, err = fs.ReadDir(h.cfg.fs, dir)
+	} else {
+		entries, err = os.ReadDir(h.folder)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isTemplateFile(entry.Name()) {
+			continue
+		}
+		if h.cfg.fs != nil {
+			files = append(files, path.Join(h.folder, entry.Name()))
+		} else {
+			files = append(files, filepath.Join(h.folder, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func isTemplateFile(name string) bool {
+	switch path.Ext(name) {
+	case ".gohtml", ".tmpl", ".html":
+		return true
+	}
+	return false
+}
+
+// componentNameFromFile turns "user-card.html" and "user_card.html" into
+// "UserCard": every hyphen or underscore separated segment starts a new word.
+func componentNameFromFile(file string) string {
+	base := path.Base(filepath.ToSlash(file))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' }) {
+		first, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(part[size:])
+	}
+	return b.String()
+}
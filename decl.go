@@ -0,0 +1,92 @@
+package hc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// templateComment matches {{/* ... */}} comments, with or without trim
+// markers. delims.commentPattern builds the same for other delimiters.
+var templateComment = regexp.MustCompile(`(?s)\{\{-?\s*/\*(.*?)\*/\s*-?\}\}`)
+
+// componentDecl holds the declarations a component file makes about itself in
+// template comments, one "@directive args..." per line:
+//
+//	{{/* @props label! href! variant * */}}
+//
+// @props lists the attributes the component accepts. Names ending in "!" are
+// required and a lone "*" lets any other attribute through. A ":kind"
+// suffix, as in count:int or options!:json, coerces the value like AttrType.
+//
+//	{{/* @deprecated use LinkButton instead */}}
+//	{{/* @deprecated-attr kind use variant */}}
+//	{{/* @renamed kind variant */}}
+//
+// @deprecated marks the whole component, @deprecated-attr one attribute,
+// and @renamed deprecates an attribute in favour of another; the rest of the
+// line is the message shown with every use.
+type componentDecl struct {
+	// props is nil when the file declares no @props.
+	props        *attrPolicy
+	deprecations deprecations
+}
+
+func parseComponentDecl(content []byte, d delims) componentDecl {
+	var decl componentDecl
+	for _, match := range d.commentPattern().FindAllSubmatch(content, -1) {
+		for _, line := range strings.Split(string(match[1]), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || !strings.HasPrefix(fields[0], "@") {
+				continue
+			}
+			switch fields[0] {
+			case "@props":
+				if decl.props == nil {
+					decl.props = &attrPolicy{
+						required: make(map[string]struct{}),
+						allowed:  make(map[string]struct{}),
+					}
+				}
+				decl.props.declare(fields[1:])
+			case "@deprecated":
+				decl.deprecations.component = true
+				decl.deprecations.message = strings.Join(fields[1:], " ")
+			case "@deprecated-attr":
+				if len(fields) > 1 {
+					decl.deprecations.deprecateAttr(fields[1], attrDeprecation{message: strings.Join(fields[2:], " ")})
+				}
+			case "@renamed":
+				if len(fields) > 2 {
+					decl.deprecations.deprecateAttr(fields[1], attrDeprecation{
+						renameTo: strings.ToLower(fields[2]),
+						message:  strings.Join(fields[3:], " "),
+					})
+				}
+			}
+		}
+	}
+	return decl
+}
+
+func (p *attrPolicy) declare(names []string) {
+	for _, name := range names {
+		if name == "*" {
+			p.allowOthers = true
+			continue
+		}
+		name, kindName, typed := strings.Cut(name, ":")
+		required := strings.HasSuffix(name, "!") || strings.HasSuffix(kindName, "!")
+		kindName = strings.TrimSuffix(kindName, "!")
+		key := strings.ToLower(strings.TrimSuffix(name, "!"))
+		if key == "" {
+			continue
+		}
+		if required {
+			p.required[key] = struct{}{}
+		}
+		p.allowed[key] = struct{}{}
+		if kind, ok := attrKinds[strings.ToLower(kindName)]; typed && ok {
+			AttrType(key, kind)(p)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package hc
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultLeftDelim  = "{{"
+	defaultRightDelim = "}}"
+)
+
+// delims are the action delimiters of a template.
+type delims struct {
+	left, right string
+}
+
+var defaultDelims = delims{left: defaultLeftDelim, right: defaultRightDelim}
+
+func newDelims(left, right string) delims {
+	if left == "" {
+		left = defaultLeftDelim
+	}
+	if right == "" {
+		right = defaultRightDelim
+	}
+	return delims{left: left, right: right}
+}
+
+// WithDelims changes the action delimiters of component templates, attribute
+// expressions and the final template pass, for example to "[[" and "]]" so
+// that client-side {{ }} syntax passes through untouched. An empty string
+// keeps the default for that side.
+func WithDelims(left, right string) Option {
+	return func(h *HC) {
+		h.cfg.delims = newDelims(left, right)
+	}
+}
+
+// WithComponentDelims overrides the delimiters used to parse one component's
+// template. Attribute expressions and the final pass keep the engine's
+// delimiters.
+func WithComponentDelims(component, left, right string) Option {
+	return func(h *HC) {
+		if component == "" {
+			return
+		}
+		if h.cfg.componentDelims == nil {
+			h.cfg.componentDelims = make(map[string]delims)
+		}
+		h.cfg.componentDelims[strings.ToLower(component)] = newDelims(left, right)
+	}
+}
+
+// componentDelims returns the delimiters the template of component is
+// parsed with.
+func (h *HC) componentDelims(component string) delims {
+	if d, ok := h.cfg.componentDelims[strings.ToLower(component)]; ok {
+		return d
+	}
+	return h.cfg.delims
+}
+
+// commentPattern matches template comments such as {{/* ... */}}, with or
+// without trim markers.
+func (d delims) commentPattern() *regexp.Regexp {
+	if d == defaultDelims {
+		return templateComment
+	}
+	return regexp.MustCompile(`(?s)` + regexp.QuoteMeta(d.left) + `-?\s*/\*(.*?)\*/\s*-?` + regexp.QuoteMeta(d.right))
+}
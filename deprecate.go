@@ -0,0 +1,208 @@
+package hc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Deprecation reports one use of a deprecated component or attribute.
+type Deprecation struct {
+	Component string
+	// Attr is the deprecated attribute, or "" when the component itself is
+	// deprecated.
+	Attr string
+	// RenamedTo is the attribute the value was moved to, if any.
+	RenamedTo string
+	Message   string
+	// Origin is where the component was invoked, and Stack the active
+	// invocations from the outermost one down to it.
+	Origin Origin
+	Stack  []ComponentFrame
+}
+
+func (d Deprecation) String() string {
+	return d.Origin.String() + ": " + d.describe()
+}
+
+// describe is the message without the location, shared with lint.
+func (d Deprecation) describe() string {
+	var msg string
+	switch {
+	case d.Attr == "":
+		msg = fmt.Sprintf("component %s is deprecated", d.Component)
+	case d.RenamedTo != "":
+		msg = fmt.Sprintf("component %s attr %q is deprecated, renamed to %q", d.Component, d.Attr, d.RenamedTo)
+	default:
+		msg = fmt.Sprintf("component %s attr %q is deprecated", d.Component, d.Attr)
+	}
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+	return msg
+}
+
+type DeprecationHandler func(context.Context, Deprecation)
+
+// deprecations describes what is deprecated about one component.
+type deprecations struct {
+	component bool
+	message   string
+	attrs     map[string]attrDeprecation
+}
+
+type attrDeprecation struct {
+	message  string
+	renameTo string
+}
+
+func (d *deprecations) deprecateAttr(name string, dep attrDeprecation) {
+	if d.attrs == nil {
+		d.attrs = make(map[string]attrDeprecation)
+	}
+	d.attrs[strings.ToLower(name)] = dep
+}
+
+func (h *HC) configDeprecations(component string) *deprecations {
+	key := strings.ToLower(component)
+	if h.cfg.deprecations == nil {
+		h.cfg.deprecations = make(map[string]*deprecations)
+	}
+	if h.cfg.deprecations[key] == nil {
+		h.cfg.deprecations[key] = &deprecations{}
+	}
+	return h.cfg.deprecations[key]
+}
+
+// WithDeprecatedComponent marks component as deprecated. Every invocation is
+// reported to the handlers registered with WithDeprecationHandler and by
+// Lint, and still renders.
+func WithDeprecatedComponent(component, message string) Option {
+	return func(h *HC) {
+		if component == "" {
+			return
+		}
+		dep := h.configDeprecations(component)
+		dep.component, dep.message = true, message
+	}
+}
+
+// WithDeprecatedAttr marks one attribute of component as deprecated.
+func WithDeprecatedAttr(component, attr, message string) Option {
+	return func(h *HC) {
+		if component == "" || attr == "" {
+			return
+		}
+		h.configDeprecations(component).deprecateAttr(attr, attrDeprecation{message: message})
+	}
+}
+
+// WithRenamedAttr deprecates the attribute from of component in favour of
+// to. Values passed as from are moved to to before the attribute rules run,
+// unless the caller passes to as well.
+func WithRenamedAttr(component, from, to string) Option {
+	return func(h *HC) {
+		if component == "" || from == "" || to == "" {
+			return
+		}
+		h.configDeprecations(component).deprecateAttr(from, attrDeprecation{renameTo: strings.ToLower(to)})
+	}
+}
+
+// WithDeprecationHandler registers a callback for every use of a deprecated
+// component or attribute during rendering.
+func WithDeprecationHandler(handler DeprecationHandler) Option {
+	return func(h *HC) {
+		if handler != nil {
+			h.cfg.deprecationHandlers = append(h.cfg.deprecationHandlers, handler)
+		}
+	}
+}
+
+// componentDeprecations merges the deprecations configured for component with
+// those declared in its file; configured ones win.
+func (h *HC) componentDeprecations(component string) deprecations {
+	var merged deprecations
+	if src, err := h.lookupComponentSource(component); err == nil {
+		merged = src.decl.deprecations
+	}
+	configured, ok := h.cfg.deprecations[strings.ToLower(component)]
+	if !ok {
+		return merged
+	}
+	if configured.component {
+		merged.component, merged.message = true, configured.message
+	}
+	if len(configured.attrs) > 0 {
+		attrs := make(map[string]attrDeprecation, len(merged.attrs)+len(configured.attrs))
+		for name, dep := range merged.attrs {
+			attrs[name] = dep
+		}
+		for name, dep := range configured.attrs {
+			attrs[name] = dep
+		}
+		merged.attrs = attrs
+	}
+	return merged
+}
+
+// find returns the deprecations that apply to an invocation passing names,
+// which must be lower-case, in the order given.
+func (d deprecations) find(component string, names []string) []Deprecation {
+	var found []Deprecation
+	if d.component {
+		found = append(found, Deprecation{Component: component, Message: d.message})
+	}
+	for _, name := range names {
+		if dep, ok := d.attrs[name]; ok {
+			found = append(found, Deprecation{Component: component, Attr: name, RenamedTo: dep.renameTo, Message: dep.message})
+		}
+	}
+	return found
+}
+
+// applyDeprecations reports the deprecated parts of an invocation and moves
+// renamed attributes to their new names in props and resolved.
+func (h *HC) applyDeprecations(state *renderState, frame ComponentFrame, props map[string]any, resolved []resolvedAttr) []resolvedAttr {
+	deps := h.componentDeprecations(frame.Component)
+	if !deps.component && len(deps.attrs) == 0 {
+		return resolved
+	}
+
+	names := make([]string, len(resolved))
+	for i, attr := range resolved {
+		names[i] = attr.Canonical
+	}
+	for _, dep := range deps.find(frame.Component, names) {
+		if dep.RenamedTo != "" {
+			resolved = renameAttr(props, resolved, dep.Attr, dep.RenamedTo)
+		}
+		dep.Origin, dep.Stack = frame.Origin, state.stack
+		for _, handler := range h.cfg.deprecationHandlers {
+			handler(state.ctx, dep)
+		}
+		h.diagnose(state.ctx, frame, "%s", dep.describe())
+	}
+	return resolved
+}
+
+func renameAttr(props map[string]any, resolved []resolvedAttr, from, to string) []resolvedAttr {
+	value := props[from]
+	delete(props, from)
+	_, explicit := props[to]
+	if !explicit {
+		props[to] = value
+	}
+
+	renamed := resolved[:0:0]
+	for _, attr := range resolved {
+		if attr.Canonical == from {
+			if explicit {
+				continue
+			}
+			attr.Name, attr.Canonical = to, to
+		}
+		renamed = append(renamed, attr)
+	}
+	return renamed
+}
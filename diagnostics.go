@@ -0,0 +1,235 @@
+package hc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+type DiagnosticHandler func(context.Context, Diagnostic)
+
+// WithDiagnostics registers a handler for the problems a render finds that do
+// not stop it: props a template uses but the caller did not pass, children a
+// template expects but did not get, deprecated usage, and unsupported
+// attributes under WithLenientAttrs. Render failures are still returned as
+// errors.
+func WithDiagnostics(handler DiagnosticHandler) Option {
+	return func(h *HC) {
+		if handler != nil {
+			h.cfg.diagnosticHandlers = append(h.cfg.diagnosticHandlers, handler)
+		}
+	}
+}
+
+// WithLenientAttrs reports attributes a component's rules do not allow as
+// warnings instead of failing the render, and passes them through.
+func WithLenientAttrs() Option {
+	return func(h *HC) {
+		h.cfg.lenientAttrs = true
+	}
+}
+
+// Diagnostics collects the diagnostics of the renders whose context carries
+// it, for showing them next to a single page:
+//
+//	var diags hc.Diagnostics
+//	err := engine.ParseFileContext(hc.ContextWithDiagnostics(ctx, &diags), w, page, data)
+//	for _, d := range diags.List() { ... }
+//
+// The zero value is ready to use and safe for concurrent renders.
+type Diagnostics struct {
+	mu    sync.Mutex
+	diags []Diagnostic
+}
+
+// List returns the diagnostics collected so far.
+func (d *Diagnostics) List() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.diags)
+}
+
+func (d *Diagnostics) add(diag Diagnostic) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.diags = append(d.diags, diag)
+}
+
+type diagnosticsKey struct{}
+
+// ContextWithDiagnostics returns a context that makes renders add their
+// diagnostics to d, in addition to the WithDiagnostics handlers.
+func ContextWithDiagnostics(ctx context.Context, d *Diagnostics) context.Context {
+	return context.WithValue(ctx, diagnosticsKey{}, d)
+}
+
+// diagnosing reports whether anything receives the diagnostics of a render
+// with ctx, so checks can be skipped when nobody listens.
+func (h *HC) diagnosing(ctx context.Context) bool {
+	if len(h.cfg.diagnosticHandlers) > 0 {
+		return true
+	}
+	_, ok := ctx.Value(diagnosticsKey{}).(*Diagnostics)
+	return ok
+}
+
+func (h *HC) diagnose(ctx context.Context, frame ComponentFrame, format string, args ...any) {
+	diag := Diagnostic{
+		Severity:  SeverityWarning,
+		Component: frame.Component,
+		Origin:    frame.Origin,
+		Message:   fmt.Sprintf(format, args...),
+	}
+	if collector, ok := ctx.Value(diagnosticsKey{}).(*Diagnostics); ok {
+		collector.add(diag)
+	}
+	for _, handler := range h.cfg.diagnosticHandlers {
+		handler(ctx, diag)
+	}
+}
+
+// templateUsage records which inputs a component template reads. Inputs
+// that are only tested, as in {{ if .Props.icon }} or {{ with .Children }},
+// are optional.
+type templateUsage struct {
+	// props maps each prop read through .Props to whether it is optional.
+	props map[string]bool
+	// needsChildren is set when the template prints .Children without ever
+	// checking for it.
+	needsChildren bool
+}
+
+// componentUsage analyses the template of component, caching the result.
+func (h *HC) componentUsage(component string) (templateUsage, bool) {
+	key := strings.ToLower(component)
+	h.cache.mu.RLock()
+	usage, ok := h.cache.usage[key]
+	h.cache.mu.RUnlock()
+	if ok {
+		return usage, true
+	}
+
+	src, err := h.lookupComponentSource(component)
+	if err != nil {
+		return templateUsage{}, false
+	}
+	d := h.componentDelims(component)
+	tree := parse.New(component)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(string(src.content), d.left, d.right, trees); err != nil {
+		return templateUsage{}, false
+	}
+	usage = analyseTemplate(trees)
+
+	h.cache.mu.Lock()
+	if h.cache.usage == nil {
+		h.cache.usage = make(map[string]templateUsage)
+	}
+	h.cache.usage[key] = usage
+	h.cache.mu.Unlock()
+	return usage, true
+}
+
+func analyseTemplate(trees map[string]*parse.Tree) templateUsage {
+	usage := templateUsage{props: make(map[string]bool)}
+	var printsChildren, checksChildren bool
+
+	var walk func(node parse.Node, tested bool)
+	field := func(ident []string, tested bool) {
+		if len(ident) > 0 && ident[0] == "$" {
+			ident = ident[1:]
+		}
+		if len(ident) == 0 {
+			return
+		}
+		switch ident[0] {
+		case "Props":
+			if len(ident) > 1 {
+				name := strings.ToLower(ident[1])
+				optional, seen := usage.props[name]
+				usage.props[name] = tested || (seen && optional)
+			}
+		case "Children", "ChildrenRaw":
+			if tested {
+				checksChildren = true
+			} else {
+				printsChildren = true
+			}
+		case "HasChildren":
+			checksChildren = true
+		}
+	}
+	walk = func(node parse.Node, tested bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, tested)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, tested)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg, tested)
+				}
+			}
+		case *parse.FieldNode:
+			field(n.Ident, tested)
+		case *parse.VariableNode:
+			field(n.Ident, tested)
+		case *parse.ChainNode:
+			walk(n.Node, tested)
+		case *parse.IfNode:
+			walk(n.Pipe, true)
+			walk(n.List, false)
+			walk(n.ElseList, false)
+		case *parse.WithNode:
+			walk(n.Pipe, true)
+			walk(n.List, false)
+			walk(n.ElseList, false)
+		case *parse.RangeNode:
+			walk(n.Pipe, true)
+			walk(n.List, false)
+			walk(n.ElseList, false)
+		case *parse.TemplateNode:
+			walk(n.Pipe, tested)
+		}
+	}
+	for _, tree := range trees {
+		walk(tree.Root, false)
+	}
+	usage.needsChildren = printsChildren && !checksChildren
+	return usage
+}
+
+// checkUsage reports props the component's template reads without testing
+// for them that the invocation did not pass, and missing children the
+// template prints unconditionally.
+func (h *HC) checkUsage(state *renderState, frame ComponentFrame, props map[string]any, children []byte) {
+	usage, ok := h.componentUsage(frame.Component)
+	if !ok {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(usage.props)) {
+		if usage.props[name] {
+			continue
+		}
+		if _, passed := props[name]; !passed {
+			h.diagnose(state.ctx, frame, "component %s uses .Props.%s, which was not passed", frame.Component, name)
+		}
+	}
+	if usage.needsChildren && len(strings.TrimSpace(string(children))) == 0 {
+		h.diagnose(state.ctx, frame, "component %s renders .Children but was given none", frame.Component)
+	}
+}
@@ -0,0 +1,192 @@
+package hc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// excerptContext is how many lines are shown around a failing line.
+const excerptContext = 4
+
+var (
+	// parseErrorLocation matches component parse errors, which name the
+	// template file followed by the html/template message and its line.
+	parseErrorLocation = regexp.MustCompile(`parse component \S+ \(([^()]+?)(?::\d+)?\): template: [^:\s]+:(\d+):`)
+	// execErrorLocation matches html/template execution errors, which name
+	// the component template and the line and column of the failing action.
+	execErrorLocation = regexp.MustCompile(`template: ([^:\s]+):(\d+):(\d+): `)
+)
+
+// WithDevErrors makes WriteError answer with a detailed error page instead
+// of a bare 500. Enable it in development only:
+//
+//	hc.WithDevErrors(os.Getenv("APP_ENV") == "development")
+func WithDevErrors(enabled bool) Option {
+	return func(h *HC) {
+		h.cfg.devErrors = enabled
+	}
+}
+
+// WriteError reports a failed render to an HTTP client with status 500. When
+// WithDevErrors is enabled the response is an HTML page showing the error,
+// the component invocation chain, the source around the failing lines and
+// the props the failing component received; otherwise it reveals nothing
+// about the failure.
+func (h *HC) WriteError(w http.ResponseWriter, err error) {
+	if !h.cfg.devErrors {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if tplErr := errorPageTemplate.Execute(&buf, h.errorPage(err)); tplErr != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(buf.Bytes())
+}
+
+type errorPage struct {
+	Message   string
+	Component string
+	Origin    string
+	Stack     []ComponentFrame
+	Excerpts  []sourceExcerpt
+	Props     []errorProp
+}
+
+type errorProp struct {
+	Name, Type, Value string
+}
+
+type sourceExcerpt struct {
+	Location string
+	Lines    []excerptLine
+}
+
+type excerptLine struct {
+	Number    int
+	Text      string
+	Highlight bool
+}
+
+func (h *HC) errorPage(err error) errorPage {
+	page := errorPage{Message: err.Error()}
+
+	var compErr *ComponentError
+	if errors.As(err, &compErr) {
+		page.Message = compErr.Err.Error()
+		page.Component = compErr.Component
+		page.Origin = compErr.Origin.String()
+		page.Stack = compErr.Stack
+		for _, name := range slices.Sorted(maps.Keys(compErr.Props)) {
+			value := compErr.Props[name]
+			page.Props = append(page.Props, errorProp{
+				Name:  name,
+				Type:  fmt.Sprintf("%T", value),
+				Value: fmt.Sprintf("%v", value),
+			})
+		}
+		if compErr.Origin.Line > 0 {
+			page.addExcerpt(h, compErr.Origin.File, compErr.Origin.Line)
+		}
+	}
+
+	if m := parseErrorLocation.FindStringSubmatch(page.Message); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		page.addExcerpt(h, m[1], line)
+	}
+	if m := execErrorLocation.FindStringSubmatch(page.Message); m != nil {
+		if src, err := h.lookupComponentSource(m[1]); err == nil {
+			line, _ := strconv.Atoi(m[2])
+			page.addExcerpt(h, src.source, line)
+		}
+	}
+	return page
+}
+
+// addExcerpt adds the lines around line of file, if the file can be read.
+func (p *errorPage) addExcerpt(h *HC, file string, line int) {
+	content, err := h.readFile(file)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return
+	}
+
+	excerpt := sourceExcerpt{Location: file + ":" + strconv.Itoa(line)}
+	for n := max(line-excerptContext, 1); n <= min(line+excerptContext, len(lines)); n++ {
+		excerpt.Lines = append(excerpt.Lines, excerptLine{
+			Number:    n,
+			Text:      lines[n-1],
+			Highlight: n == line,
+		})
+	}
+	p.Excerpts = append(p.Excerpts, excerpt)
+}
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Render error{{ with .Component }} in {{ . }}{{ end }}</title>
+<style>
+body { font: 15px/1.5 system-ui, sans-serif; margin: 0; background: #fff8f8; color: #1f2328; }
+header { background: #cf222e; color: #fff; padding: 1.25rem 2rem; }
+header h1 { margin: 0; font-size: 1.1rem; font-weight: 600; }
+header p { margin: .25rem 0 0; font: 14px/1.4 ui-monospace, monospace; white-space: pre-wrap; }
+main { padding: 1rem 2rem 2rem; }
+h2 { font-size: 1rem; margin: 1.5rem 0 .5rem; }
+ol { margin: 0; padding-left: 1.5rem; font: 13px/1.6 ui-monospace, monospace; }
+ol li:last-child { font-weight: 600; }
+pre { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin: 0; padding: .5rem 0; overflow: auto; font: 13px/1.5 ui-monospace, monospace; }
+pre span { display: block; padding: 0 1rem; }
+pre span.hl { background: #ffebe9; box-shadow: inset 3px 0 #cf222e; }
+pre i { display: inline-block; width: 3em; color: #8c959f; font-style: normal; user-select: none; }
+table { border-collapse: collapse; font: 13px/1.5 ui-monospace, monospace; }
+td, th { text-align: left; padding: .25rem 1.5rem .25rem 0; vertical-align: top; }
+th { font-family: system-ui, sans-serif; }
+</style>
+</head>
+<body>
+<header>
+<h1>Render error{{ with .Component }} in &lt;{{ . }}&gt;{{ end }}{{ with .Origin }} at {{ . }}{{ end }}</h1>
+<p>{{ .Message }}</p>
+</header>
+<main>
+{{ with .Stack -}}
+<h2>Component chain</h2>
+<ol>
+{{ range . }}<li>&lt;{{ .Component }}&gt;{{ with .Origin.String }} {{ . }}{{ end }}</li>
+{{ end -}}
+</ol>
+{{- end }}
+{{ range .Excerpts -}}
+<h2>{{ .Location }}</h2>
+<pre>{{ range .Lines }}<span{{ if .Highlight }} class="hl"{{ end }}><i>{{ .Number }}</i>{{ .Text }}</span>{{ end }}</pre>
+{{ end -}}
+{{ with .Props -}}
+<h2>Props</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Value</th></tr>
+{{ range . }}<tr><td>{{ .Name }}</td><td>{{ .Type }}</td><td>{{ .Value }}</td></tr>
+{{ end -}}
+</table>
+{{- end }}
+</main>
+</body>
+</html>
+`))
@@ -4,48 +4,76 @@ import (
 	"bytes"
 	"context"
 	"embed"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"html"
 	"html/template"
 	"io"
+	"io/fs"
 	"maps"
+	"math/rand/v2"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	texttmpl "text/template"
+	"text/template/parse"
 	"time"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/esrid/hc/internal/markup"
 )
 
 var ErrEmptyFile = errors.New("file is empty")
 
-const maxComponentPasses = 16
+// defaultMaxDepth is how deeply components may nest unless WithMaxDepth
+// says otherwise.
+const defaultMaxDepth = 16
+
+// maxAttrCacheEntries bounds the shared attribute expression cache so values
+// echoed from request data cannot grow it without limit.
+const maxAttrCacheEntries = 4096
 
 type HC struct {
 	folder string
 	cfg    Config
 
+	// spans hands out instrumentation span IDs.
+	spans atomic.Uint64
+	// exprValues holds the values built-in expressions captured, under the
+	// ID the capture printed, until evaluateExpr collects them; exprIDs
+	// hands out the IDs.
+	exprValues sync.Map
+	exprIDs    atomic.Uint64
+
 	cache struct {
 		mu      sync.RWMutex
 		entries map[string]cacheEntry
 		sources map[string]componentSource
+		attrs   map[string]*texttmpl.Template
+		// usage holds what each component template reads, for diagnostics.
+		usage map[string]templateUsage
+		// shadowedBuiltins records which built-in names a component file
+		// takes over.
+		shadowedBuiltins map[string]bool
 	}
 }
 
 type cacheEntry struct {
-	tpl *template.Template
+	tpl    *template.Template
+	source string
 }
 
 type componentSource struct {
 	content []byte
 	source  string
+	decl    componentDecl
 }
 
 type PostProcessor func(context.Context, []byte, any, template.FuncMap) ([]byte, error)
@@ -57,6 +85,12 @@ type ComponentInstrumentationStage string
 const (
 	ComponentStageBegin ComponentInstrumentationStage = "begin"
 	ComponentStageEnd   ComponentInstrumentationStage = "end"
+	// PageStageBegin and PageStageEnd bracket a whole page render from
+	// ParseFile, ParseFileContext or ParseFileTemplate. They only reach
+	// hooks registered with WithPageInstrumentation. Page events have no
+	// Component; their Source is the page file.
+	PageStageBegin ComponentInstrumentationStage = "page_begin"
+	PageStageEnd   ComponentInstrumentationStage = "page_end"
 )
 
 type ComponentInstrumentationEvent struct {
@@ -64,6 +98,27 @@ type ComponentInstrumentationEvent struct {
 	Stage     ComponentInstrumentationStage
 	Err       error
 	Duration  time.Duration
+	// Origin is where the component was invoked, and Stack the active
+	// invocations from the outermost one down to this component.
+	Origin Origin
+	Stack  []ComponentFrame
+	// Depth is the component's nesting level, starting at 1 for components
+	// invoked by the page; page events have depth 0.
+	Depth int
+	// SpanID identifies this render and is shared by its begin and end
+	// events. ParentID is the span of the enclosing component or page, or
+	// zero at the root. Pages only get a span when WithPageInstrumentation
+	// hooks are registered.
+	SpanID   uint64
+	ParentID uint64
+	// Source is the template file that was rendered, and Cached reports
+	// whether its parsed template came from the cache.
+	Source string
+	Cached bool
+	// PropsCount and Bytes describe the input and output of the render.
+	// They are set on end events once known.
+	PropsCount int
+	Bytes      int
 }
 
 type ComponentInstrumentationHook func(context.Context, ComponentInstrumentationEvent)
@@ -76,10 +131,19 @@ type attrPolicy struct {
 	required    map[string]struct{}
 	allowed     map[string]struct{}
 	allowOthers bool
+	// checks holds the value rules of each attribute, in the order they
+	// were registered.
+	checks   map[string][]attrCheck
+	defaults map[string]any
+	groups   []attrGroup
+	// kinds, enums and patterns record the checks' rules for Components.
+	kinds    map[string]AttrKind
+	enums    map[string][]string
+	patterns map[string]string
 }
 
 type Config struct {
-	fs                  *embed.FS
+	fs                  fs.FS
 	funcMap             template.FuncMap
 	funcMapProvider     func(context.Context) template.FuncMap
 	dataAugmenter       func(context.Context, any) any
@@ -93,6 +157,22 @@ type Config struct {
 	componentAugmenters map[string][]ComponentAugmenter
 	attrPolicies        map[string]attrPolicy
 	instrumentHooks     []ComponentInstrumentationHook
+	pageInstrumentHooks []ComponentInstrumentationHook
+	maxDepth            int
+	devErrors           bool
+	delims              delims
+	componentDelims     map[string]delims
+	deprecations        map[string]*deprecations
+	deprecationHandlers []DeprecationHandler
+	eventAttrs          map[string]struct{}
+	diagnosticHandlers  []DiagnosticHandler
+	lenientAttrs        bool
+	strictMissingKeys   bool
+	strictComponents    map[string]struct{}
+	inferAllAttrs       bool
+	inferAttrs          map[string]struct{}
+	beforeRenderHooks   []BeforeRenderHook
+	afterRenderHooks    []AfterRenderHook
 }
 
 type Option func(*HC)
@@ -101,8 +181,11 @@ func NewHC(folder string, opts ...Option) *HC {
 	hc := &HC{folder: folder}
 	hc.cache.entries = make(map[string]cacheEntry)
 	hc.cache.sources = make(map[string]componentSource)
+	hc.cache.attrs = make(map[string]*texttmpl.Template)
 	hc.cfg.componentAugmenters = make(map[string][]ComponentAugmenter)
 	hc.cfg.attrPolicies = make(map[string]attrPolicy)
+	hc.cfg.maxDepth = defaultMaxDepth
+	hc.cfg.delims = defaultDelims
 	for _, opt := range opts {
 		opt(hc)
 	}
@@ -113,8 +196,15 @@ func NewHC(folder string, opts ...Option) *HC {
 }
 
 func WithFS(fs embed.FS) Option {
+	return WithFileSystem(fs)
+}
+
+// WithFileSystem reads pages and components from fsys instead of the host
+// filesystem. Paths are slash-separated and relative to the root of fsys, so
+// fstest.MapFS works for tests.
+func WithFileSystem(fsys fs.FS) Option {
 	return func(h *HC) {
-		h.cfg.fs = &fs
+		h.cfg.fs = fsys
 	}
 }
 
@@ -278,6 +368,29 @@ func AllowOtherAttrs() AttrRuleOption {
 	}
 }
 
+// WithMaxDepth sets how deeply components may nest inside each other, which
+// bounds legitimately recursive components such as tree menus. The default
+// is 16.
+func WithMaxDepth(depth int) Option {
+	return func(h *HC) {
+		if depth > 0 {
+			h.cfg.maxDepth = depth
+		}
+	}
+}
+
+// WithPageInstrumentation registers a hook for the PageStageBegin and
+// PageStageEnd events around every page render. Components invoked by the
+// page then report the page's span as their ParentID, so component and page
+// hooks together see the whole trace tree.
+func WithPageInstrumentation(hook ComponentInstrumentationHook) Option {
+	return func(h *HC) {
+		if hook != nil {
+			h.cfg.pageInstrumentHooks = append(h.cfg.pageInstrumentHooks, hook)
+		}
+	}
+}
+
 func WithComponentInstrumentation(hook ComponentInstrumentationHook) Option {
 	return func(h *HC) {
 		if hook == nil {
@@ -292,54 +405,118 @@ func (h *HC) ParseFile(writer io.Writer, filename string, data any) error {
 }
 
 func (h *HC) ParseFileContext(ctx context.Context, writer io.Writer, filename string, data any) error {
-	raw, state, err := h.prepareRenderState(ctx, filename, data)
-	if err != nil {
-		return err
+	return h.renderPage(ctx, writer, filename, data, h.cfg.finalTemplatePass, h.cfg.streamingWrites)
+}
+
+func (h *HC) ParseFileTemplate(ctx context.Context, writer io.Writer, filename string, data any) error {
+	return h.renderPage(ctx, writer, filename, data, true, false)
+}
+
+// renderPage renders a page file into writer between page instrumentation
+// events and page hooks. Output streams only when nothing post-processes or
+// inspects the whole page.
+func (h *HC) renderPage(ctx context.Context, writer io.Writer, filename string, data any, finalPass, stream bool) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	canStream := h.cfg.streamingWrites && writer != nil && !h.cfg.finalTemplatePass && len(h.cfg.postProcessors) == 0 && len(h.cfg.pagePipelines) == 0
-	if canStream {
-		return h.renderStreaming(state, raw, writer)
+	event := ComponentInstrumentationEvent{
+		Stage:  PageStageBegin,
+		Source: filename,
 	}
+	if len(h.cfg.pageInstrumentHooks) > 0 {
+		event.SpanID = h.spans.Add(1)
+	}
+	start := time.Now()
+	h.emitPageInstrumentation(ctx, event)
 
-	rendered, err := h.renderMarkupBytes(state, raw, 0)
-	if err != nil {
-		return err
+	out := &countingWriter{w: writer}
+	defer func() {
+		event.Stage = PageStageEnd
+		event.Err = err
+		event.Duration = time.Since(start)
+		event.Bytes = out.n
+		h.emitPageInstrumentation(ctx, event)
+	}()
+
+	page := &PageRender{Page: filename, Data: data}
+	var final []byte
+	var state *renderState
+	for _, hook := range h.cfg.beforeRenderHooks {
+		if err = hook(ctx, page); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		canStream := stream && writer != nil && !finalPass && len(h.cfg.postProcessors) == 0 && len(h.cfg.pagePipelines) == 0 && len(h.cfg.afterRenderHooks) == 0
+		final, state, err = h.renderPageBytes(ctx, filename, page.Data, finalPass, event.SpanID, canStream, out)
+		if canStream {
+			return err
+		}
 	}
 
-	final, err := h.applyPostProcessing(state, rendered, h.cfg.finalTemplatePass)
+	if len(h.cfg.afterRenderHooks) > 0 {
+		page.Duration = time.Since(start)
+		if state != nil {
+			page.Components = *state.components
+		}
+		page.Output, page.Bytes, page.Err = final, len(final), err
+		for _, hook := range h.cfg.afterRenderHooks {
+			hook(ctx, page)
+		}
+		final, err = page.Output, page.Err
+	}
 	if err != nil {
 		return err
 	}
 
-	if writer != nil {
-		_, err = writer.Write(final)
-		return err
+	if writer == nil {
+		out.n = len(final)
+		return nil
 	}
-	return nil
+	_, err = out.Write(final)
+	return err
 }
 
-func (h *HC) ParseFileTemplate(ctx context.Context, writer io.Writer, filename string, data any) error {
+// renderPageBytes renders a page file, or streams it into out when stream
+// is set. The returned state is nil when the page could not be read.
+func (h *HC) renderPageBytes(ctx context.Context, filename string, data any, finalPass bool, span uint64, stream bool, out io.Writer) ([]byte, *renderState, error) {
 	raw, state, err := h.prepareRenderState(ctx, filename, data)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	state.span = span
+	if finalPass {
+		state.delimMarker = fmt.Sprintf("%020d%020d", rand.Uint64(), rand.Uint64())
 	}
 
-	rendered, err := h.renderMarkupBytes(state, raw, 0)
-	if err != nil {
-		return err
+	if stream {
+		return nil, state, h.renderStreaming(state, raw, out)
 	}
 
-	final, err := h.applyPostProcessing(state, rendered, true)
+	rendered, err := h.renderMarkupBytes(state, raw)
 	if err != nil {
-		return err
+		return nil, state, err
 	}
+	final, err := h.applyPostProcessing(state, rendered, finalPass)
+	return final, state, err
+}
 
-	if writer != nil {
-		_, err = writer.Write(final)
-		return err
+// countingWriter counts the bytes written through it; a nil w discards them.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.w == nil {
+		c.n += len(p)
+		return len(p), nil
 	}
-	return nil
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
 }
 
 func (h *HC) prepareRenderState(ctx context.Context, filename string, data any) ([]byte, *renderState, error) {
@@ -355,6 +532,10 @@ func (h *HC) prepareRenderState(ctx context.Context, filename string, data any)
 		return nil, nil, ErrEmptyFile
 	}
 
+	return raw, h.newRenderState(ctx, data, Origin{File: filename, Line: 1, Column: 1}), nil
+}
+
+func (h *HC) newRenderState(ctx context.Context, data any, source Origin) *renderState {
 	mergedFuncs := h.mergedFuncMap(ctx)
 	augmented := data
 	if h.cfg.dataAugmenter != nil {
@@ -364,88 +545,170 @@ func (h *HC) prepareRenderState(ctx context.Context, filename string, data any)
 	}
 
 	state := &renderState{
-		ctx:   ctx,
-		funcs: mergedFuncs,
-		data:  h.dataWithContext(augmented, ctx),
+		ctx:            ctx,
+		funcs:          mergedFuncs,
+		data:           h.dataWithContext(augmented, ctx),
+		childrenMarker: fmt.Sprintf("%020d%020d", rand.Uint64(), rand.Uint64()),
+		source:         source,
+		components:     new(int),
 	}
-	return raw, state, nil
+	if h.cfg.funcMapProvider != nil {
+		state.attrs = make(map[string]*texttmpl.Template)
+	}
+	return state
+}
+
+// ComponentCall describes a component invocation made from Go rather than
+// from markup.
+type ComponentCall struct {
+	// Props are handed to the component as already evaluated attributes.
+	Props map[string]any
+	// Children is the markup nested inside the component.
+	Children string
+	// Data is the root data, visible as .Root and .Data.
+	Data any
+}
+
+// RenderComponent renders the component name on its own, as if a page had
+// invoked it with the props and children in call. Page post-processing and
+// the final template pass do not run.
+func (h *HC) RenderComponent(ctx context.Context, writer io.Writer, name string, call ComponentCall) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if writer == nil {
+		writer = io.Discard
+	}
+
+	state := h.newRenderState(ctx, call.Data, Origin{})
+	tag := componentTag{
+		Name:        name,
+		Children:    []byte(call.Children),
+		SelfClosing: call.Children == "",
+		props:       call.Props,
+	}
+	rendered, err := h.renderComponent(state, tag)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(rendered)
+	return err
 }
 
 func (h *HC) renderStreaming(state *renderState, input []byte, writer io.Writer) error {
 	if writer == nil {
 		return errors.New("streaming requires a writer")
 	}
-	return h.renderMarkupStream(state, input, writer, 0)
+	return h.renderMarkupStream(state, input, writer)
 }
 
-func (h *HC) renderMarkupBytes(state *renderState, input []byte, depth int) ([]byte, error) {
+func (h *HC) renderMarkupBytes(state *renderState, input []byte) ([]byte, error) {
 	var buf bytes.Buffer
-	if err := h.renderMarkupStream(state, input, &buf, depth); err != nil {
+	if err := h.renderMarkupStream(state, input, &buf); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
-func (h *HC) renderMarkupStream(state *renderState, input []byte, writer io.Writer, depth int) error {
-	decoder := xml.NewDecoder(bytes.NewReader(input))
-	decoder.Strict = false
-	decoder.AutoClose = xml.HTMLAutoClose
+func (h *HC) renderMarkupStream(state *renderState, input []byte, writer io.Writer) error {
+	var branch branchState
+	positions := markup.NewPositioner(input)
+	err := walkMarkup(input,
+		func(text []byte) error {
+			if len(bytes.TrimSpace(text)) > 0 {
+				branch.reset()
+			}
+			_, err := writer.Write(text)
+			return err
+		},
+		func(tag componentTag) error {
+			tag.Origin = state.source.advance(positions.At(tag.Offset))
+			tag.ChildrenOrigin = state.source.advance(positions.At(tag.ChildrenOffset))
+
+			if builtin, ok := h.builtin(tag.Name); ok {
+				frame := ComponentFrame{Component: tag.Name, Origin: tag.Origin}
+				if err := builtin(h, state, tag, writer, &branch); err != nil {
+					return wrapComponentError(err, frame, append(slices.Clone(state.stack), frame))
+				}
+				return nil
+			}
+			branch.reset()
+
+			rendered, err := h.renderComponent(state, tag)
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write(rendered)
+			return err
+		},
+	)
+
+	var unclosed *unclosedTagError
+	if errors.As(err, &unclosed) {
+		frame := ComponentFrame{Component: unclosed.name, Origin: state.source.advance(positions.At(unclosed.offset))}
+		return wrapComponentError(err, frame, append(slices.Clone(state.stack), frame))
+	}
+	return err
+}
+
+// componentTag is a component invocation found while scanning markup.
+type componentTag struct {
+	Name        string
+	Attrs       []markup.Attr
+	SelfClosing bool
+	// Children is the markup between the opening and closing tags.
+	Children []byte
+	// Offset is where the opening tag starts in the scanned input, and
+	// ChildrenOffset where Children starts.
+	Offset         int
+	ChildrenOffset int
+	// Origin and ChildrenOrigin are the same positions in the source file.
+	Origin         Origin
+	ChildrenOrigin Origin
+	// props, when not nil, replaces attribute evaluation for invocations
+	// made through RenderComponent.
+	props map[string]any
+}
+
+// walkMarkup scans input and hands plain markup to text and every top-level
+// component tag, including its children, to component.
+func walkMarkup(input []byte, text func([]byte) error, component func(componentTag) error) error {
+	z := markup.NewTokenizer(input)
 
 	cursor := 0
 	for {
-		startOffset := decoder.InputOffset()
-		token, err := decoder.Token()
-		if err == io.EOF {
+		tok, ok := z.Next()
+		if !ok {
 			break
 		}
-		if err != nil {
-			return err
-		}
-
-		startElem, ok := token.(xml.StartElement)
-		if !ok || !isComponentName(startElem.Name.Local) {
+		if (tok.Kind != markup.StartTag && tok.Kind != markup.SelfClosingTag) || !isComponentName(tok.Name) {
 			continue
 		}
 
-		depthCount := 1
-		var endOffset int64
-		for depthCount > 0 {
-			token, err = decoder.Token()
-			if err == io.EOF {
-				return fmt.Errorf("unclosed component tag: %s", startElem.Name.Local)
-			}
-			if err != nil {
-				return err
-			}
-			switch token.(type) {
-			case xml.StartElement:
-				depthCount++
-			case xml.EndElement:
-				depthCount--
-			}
-			if depthCount == 0 {
-				endOffset = decoder.InputOffset()
-			}
+		tag := componentTag{
+			Name:           tok.Name,
+			Attrs:          tok.Attrs,
+			SelfClosing:    tok.Kind == markup.SelfClosingTag,
+			Offset:         tok.Start,
+			ChildrenOffset: tok.End,
 		}
-
-		start := int(startOffset)
-		end := int(endOffset)
-		if start < 0 || end > len(input) || start >= end {
-			return fmt.Errorf("invalid offsets for component %s", startElem.Name.Local)
+		end := tok.End
+		if !tag.SelfClosing {
+			closeStart, closeEnd, ok := findComponentEnd(z, tok.Name)
+			if !ok {
+				return &unclosedTagError{name: tok.Name, offset: tok.Start}
+			}
+			tag.Children = input[tok.End:closeStart]
+			end = closeEnd
 		}
 
-		if start > cursor {
-			if _, err := writer.Write(input[cursor:start]); err != nil {
+		if tok.Start > cursor {
+			if err := text(input[cursor:tok.Start]); err != nil {
 				return err
 			}
 		}
 
-		rendered, err := h.renderComponent(state, startElem, input[start:end], depth+1)
-		if err != nil {
-			return err
-		}
-
-		if err := h.renderMarkupStream(state, rendered, writer, depth+1); err != nil {
+		if err := component(tag); err != nil {
 			return err
 		}
 
@@ -453,12 +716,36 @@ func (h *HC) renderMarkupStream(state *renderState, input []byte, writer io.Writ
 	}
 
 	if cursor < len(input) {
-		_, err := writer.Write(input[cursor:])
-		return err
+		return text(input[cursor:])
 	}
 	return nil
 }
 
+// findComponentEnd advances z to the end tag matching an open component tag
+// and returns its offsets. Only tags with the same name are counted, so
+// optional HTML end tags inside the component do not matter.
+func findComponentEnd(z *markup.Tokenizer, name string) (int, int, bool) {
+	depth := 1
+	for {
+		tok, ok := z.Next()
+		if !ok {
+			return 0, 0, false
+		}
+		if tok.Name != name {
+			continue
+		}
+		switch tok.Kind {
+		case markup.StartTag:
+			depth++
+		case markup.EndTag:
+			depth--
+			if depth == 0 {
+				return tok.Start, tok.End, true
+			}
+		}
+	}
+}
+
 func (h *HC) applyPostProcessing(state *renderState, input []byte, enableFinalTemplate bool) ([]byte, error) {
 	current := input
 	var err error
@@ -501,23 +788,24 @@ func (h *HC) applyPostProcessing(state *renderState, input []byte, enableFinalTe
 	return current, nil
 }
 
-func (h *HC) emitInstrumentation(ctx context.Context, component string, stage ComponentInstrumentationStage, err error, duration time.Duration) {
-	if len(h.cfg.instrumentHooks) == 0 {
-		return
-	}
-	event := ComponentInstrumentationEvent{
-		Component: component,
-		Stage:     stage,
-		Err:       err,
-		Duration:  duration,
-	}
+func (h *HC) emitInstrumentation(ctx context.Context, event ComponentInstrumentationEvent) {
 	for _, hook := range h.cfg.instrumentHooks {
 		hook(ctx, event)
 	}
 }
 
+func (h *HC) emitPageInstrumentation(ctx context.Context, event ComponentInstrumentationEvent) {
+	for _, hook := range h.cfg.pageInstrumentHooks {
+		hook(ctx, event)
+	}
+}
+
+// executeFinalTemplate runs the expanded page through html/template. Only the
+// page's own markup acts as template source: delimiter openings in component
+// output were swapped for the delimiter marker, which is swapped back once the
+// pass is done.
 func (h *HC) executeFinalTemplate(state *renderState, input []byte) ([]byte, error) {
-	tpl := template.New("hc-final").Option("missingkey=zero")
+	tpl := template.New("hc-final").Delims(h.cfg.delims.left, h.cfg.delims.right).Option(missingKeyOption(h.strictKeys("")))
 	if len(state.funcs) > 0 {
 		tpl = tpl.Funcs(state.funcs)
 	}
@@ -531,7 +819,57 @@ func (h *HC) executeFinalTemplate(state *renderState, input []byte) ([]byte, err
 	if err := parsed.Execute(&buf, state.data); err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	if state.delimMarker == "" {
+		return buf.Bytes(), nil
+	}
+	out := buf.Bytes()
+	left := h.cfg.delims.left
+	for i := range len(left) {
+		out = bytes.ReplaceAll(out, []byte(state.delimMarker+string(rune('a'+i))), []byte(left[i:i+1]))
+	}
+	return out, nil
+}
+
+// neutralizeDelims swaps the first byte of every left delimiter in
+// component output for the delimiter marker, so the final pass reads no
+// actions in it. A partial delimiter at either end is swapped too, since
+// it could join with the markup around the component into a whole one.
+func (h *HC) neutralizeDelims(state *renderState, output []byte) []byte {
+	left := []byte(h.cfg.delims.left)
+	marker := func(i int) []byte {
+		return []byte(state.delimMarker + string(rune('a'+i)))
+	}
+	if !bytes.ContainsAny(output, string(left)) {
+		return output
+	}
+
+	var b bytes.Buffer
+	b.Grow(len(output))
+	for i := 0; i < len(output); i++ {
+		rest := output[i:]
+		switch {
+		case bytes.HasPrefix(rest, left),
+			// A partial delimiter ending the output.
+			len(rest) < len(left) && bytes.HasPrefix(left, rest):
+			b.Write(marker(0))
+		case i == 0 && leadingDelimTail(left, output) > 0:
+			b.Write(marker(leadingDelimTail(left, output)))
+		default:
+			b.WriteByte(output[i])
+		}
+	}
+	return b.Bytes()
+}
+
+// leadingDelimTail returns the index in left of the partial delimiter that
+// starts output, or 0.
+func leadingDelimTail(left, output []byte) int {
+	for i := 1; i < len(left); i++ {
+		if bytes.HasPrefix(output, left[i:]) {
+			return i
+		}
+	}
+	return 0
 }
 
 func (h *HC) applyComponentAugmenters(state *renderState, component string, payload map[string]any) error {
@@ -558,39 +896,394 @@ func (h *HC) applyComponentAugmenters(state *renderState, component string, payl
 	return nil
 }
 
-func (h *HC) validateAttributes(component string, props map[string]any) error {
-	if len(h.cfg.attrPolicies) == 0 {
+// validateAttributes applies the component's attribute rules to props:
+// names and groups are checked, values are validated and converted in place
+// (in both props and resolved), and defaults fill in omitted attributes.
+// Under WithLenientAttrs unsupported attributes are reported as diagnostics
+// and kept.
+func (h *HC) validateAttributes(state *renderState, frame ComponentFrame, props map[string]any, resolved []resolvedAttr) error {
+	component := frame.Component
+	policy, ok := h.attrPolicy(component)
+	if !ok {
 		return nil
 	}
 
-	policy, ok := h.cfg.attrPolicies[strings.ToLower(component)]
-	if !ok {
-		return nil
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	missing, unsupported := policy.check(names)
+	for _, name := range missing {
+		if _, ok := policy.defaults[name]; !ok {
+			return fmt.Errorf("component %s missing required attr %q", component, name)
+		}
+	}
+	if len(unsupported) > 0 {
+		if !h.cfg.lenientAttrs {
+			return fmt.Errorf("component %s received unsupported attr %q", component, unsupported[0])
+		}
+		for _, name := range unsupported {
+			h.diagnose(state.ctx, frame, "component %s received unsupported attr %q", component, name)
+		}
+	}
+	if problem := policy.checkGroups(func(name string) bool { _, ok := props[name]; return ok }); problem != "" {
+		return fmt.Errorf("component %s %s", component, problem)
+	}
+	if name, err := policy.checkValues(props); err != nil {
+		return fmt.Errorf("component %s attr %q: %w", component, name, err)
+	}
+	for i := range resolved {
+		resolved[i].Value = props[resolved[i].Canonical]
 	}
 
-	for req := range policy.required {
-		if _, ok := props[req]; !ok {
-			return fmt.Errorf("component %s missing required attr %q", component, req)
+	for name, value := range policy.defaults {
+		if _, ok := props[name]; !ok {
+			props[name] = value
 		}
 	}
+	return nil
+}
 
-	if policy.allowOthers {
-		return nil
+// attrPolicy returns the rules registered with WithAttrRules for component,
+// falling back to the @props declared in its file.
+func (h *HC) attrPolicy(component string) (attrPolicy, bool) {
+	if policy, ok := h.cfg.attrPolicies[strings.ToLower(component)]; ok {
+		return policy, true
 	}
+	src, err := h.lookupComponentSource(component)
+	if err != nil || src.decl.props == nil {
+		return attrPolicy{}, false
+	}
+	return *src.decl.props, true
+}
 
-	for name := range props {
-		if _, ok := policy.allowed[name]; !ok {
-			return fmt.Errorf("component %s received unsupported attr %q", component, name)
+// check returns, in sorted order, the required attributes missing from names
+// and the names the policy does not allow. Names must be lower-case.
+func (p attrPolicy) check(names []string) (missing, unsupported []string) {
+	present := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		present[name] = struct{}{}
+		if _, ok := p.allowed[name]; !ok && !p.allowOthers {
+			unsupported = append(unsupported, name)
 		}
 	}
-
-	return nil
+	for req := range p.required {
+		if _, ok := present[req]; !ok {
+			missing = append(missing, req)
+		}
+	}
+	slices.Sort(missing)
+	slices.Sort(unsupported)
+	return missing, unsupported
 }
 
 type renderState struct {
 	ctx   context.Context
 	funcs template.FuncMap
 	data  any
+	// attrs memoizes attribute expressions for a single render when a
+	// func map provider makes the shared cache unsafe to use.
+	attrs map[string]*texttmpl.Template
+	// locals holds variables bound by enclosing built-ins such as <For>.
+	locals map[string]any
+	// parent holds the props of the innermost enclosing component, exposed to
+	// attribute expressions in its children as .Parent.
+	parent map[string]any
+	// provided holds the values ancestor components published for their
+	// subtree; descendants read them as .Context.
+	provided map[string]any
+	// delimMarker, followed by a letter naming a byte of the left
+	// delimiter ("a" for the first), stands in for that byte in component
+	// output while a final template pass is pending, so rendered data
+	// cannot smuggle actions into it. It is empty when no final pass runs.
+	delimMarker string
+	// emitted is set while expanding markup a component template produced.
+	// Its attribute values are final text: anything in them that looks like
+	// an action came from data.
+	emitted bool
+	// childrenMarker stands in for .Children while a component template
+	// executes and is swapped for the rendered children afterwards.
+	// enclosingChildren is the raw children markup of the component whose
+	// template output is being expanded. Components that template passes
+	// .Children to see it as their ChildrenRaw instead of the marker.
+	enclosingChildren []byte
+	childrenMarker    string
+	// components counts the components rendered so far; every scope of a
+	// render shares it.
+	components *int
+	// source is the origin of the first byte of the markup being scanned.
+	source Origin
+	// stack holds the active component invocations, outermost first.
+	stack []ComponentFrame
+	// active holds the resolved input of each frame in stack.
+	active []activeComponent
+	// span is the instrumentation span of the innermost component or page
+	// being rendered.
+	span uint64
+}
+
+type activeComponent struct {
+	props    map[string]any
+	children []byte
+}
+
+// findCycle returns the frames from an earlier invocation of component with
+// the same props and children up to the current one. Such an invocation can
+// only ever expand into itself again, unlike a recursive component walking
+// down a tree.
+func (s *renderState) findCycle(component string, props map[string]any, children []byte) []ComponentFrame {
+	for i, active := range s.active {
+		if s.stack[i].Component != component || !bytes.Equal(active.children, children) {
+			continue
+		}
+		if reflect.DeepEqual(active.props, props) {
+			return s.stack[i:]
+		}
+	}
+	return nil
+}
+
+// withSource returns a copy of the state for scanning markup that starts at
+// origin.
+func (s *renderState) withSource(origin Origin) *renderState {
+	child := *s
+	child.source = origin
+	return &child
+}
+
+// push returns a copy of the state with frame on top of the invocation stack.
+func (s *renderState) push(frame ComponentFrame) *renderState {
+	child := *s
+	child.stack = make([]ComponentFrame, len(s.stack), len(s.stack)+1)
+	copy(child.stack, s.stack)
+	child.stack = append(child.stack, frame)
+	return &child
+}
+
+// withProvided returns a copy of the state whose provided values include
+// the entries of published, which is the payload's Provide map.
+func (s *renderState) withProvided(published any) *renderState {
+	values, ok := published.(map[string]any)
+	if !ok || len(values) == 0 {
+		return s
+	}
+	child := *s
+	child.provided = make(map[string]any, len(s.provided)+len(values))
+	maps.Copy(child.provided, s.provided)
+	maps.Copy(child.provided, values)
+	return &child
+}
+
+// withLocals returns a copy of the state with vars layered over the locals
+// already in scope.
+func (s *renderState) withLocals(vars map[string]any) *renderState {
+	child := *s
+	child.locals = make(map[string]any, len(s.locals)+len(vars))
+	maps.Copy(child.locals, s.locals)
+	maps.Copy(child.locals, vars)
+	return &child
+}
+
+// withParent returns a copy of the state for markup nested inside a
+// component whose resolved props are props.
+func (s *renderState) withParent(props map[string]any) *renderState {
+	child := *s
+	child.parent = props
+	return &child
+}
+
+// scopeData returns the value the attribute expression tpl executes
+// against. At the top of a page that is the root data itself; inside loops
+// and component children it is a map that overlays locals and .Parent onto
+// the root data, with .Root still pointing at the page data. When tpl only
+// reaches the root through field chains, only those fields and methods are
+// copied into the map, so struct data works the same as maps and large
+// roots are not copied for every scope. A bare . or $ gets the whole root:
+// the root itself when tpl uses none of the overlaid names, or else a copy
+// of a map root. Struct roots fall back to the copied fields in that case;
+// .Root reaches the rest.
+func (s *renderState) scopeData(tpl *texttmpl.Template) (any, error) {
+	if len(s.locals) == 0 && s.parent == nil {
+		return s.data, nil
+	}
+
+	names, whole := rootFields(tpl.Tree)
+	root, isMap := s.data.(map[string]any)
+	if whole && !s.overlays(names, root, isMap) {
+		return s.data, nil
+	}
+
+	scope := make(map[string]any, len(names)+len(s.locals)+4)
+	switch {
+	case isMap && whole:
+		maps.Copy(scope, root)
+	case isMap:
+		for _, name := range names {
+			if value, ok := root[name]; ok {
+				scope[name] = value
+			}
+		}
+	default:
+		for _, name := range names {
+			value, ok, err := fieldValue(s.data, name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				scope[name] = value
+			}
+		}
+	}
+	extras := map[string]any{"Root": s.data, "Data": s.data, "Ctx": s.ctx}
+	if isMap {
+		extras = map[string]any{"Root": s.data}
+	}
+	for name, value := range extras {
+		if _, exists := scope[name]; exists {
+			continue
+		}
+		if _, exists := root[name]; !exists {
+			scope[name] = value
+		}
+	}
+	if s.parent != nil {
+		scope["Parent"] = s.parent
+	}
+	maps.Copy(scope, s.locals)
+	return scope, nil
+}
+
+// overlays reports whether any of names is one scopeData supplies on top of
+// the root data.
+func (s *renderState) overlays(names []string, root map[string]any, isMap bool) bool {
+	for _, name := range names {
+		if _, ok := s.locals[name]; ok {
+			return true
+		}
+		switch name {
+		case "Parent":
+			if s.parent != nil {
+				return true
+			}
+		case "Root", "Data", "Ctx":
+			if isMap {
+				if _, ok := root[name]; !ok && name == "Root" {
+					return true
+				}
+			} else if _, ok, _ := fieldValue(s.data, name); !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rootFields lists the names tree looks up on its data, as in .Title or
+// $.Title, and reports whether it also uses the root data as a whole, as in
+// {{ index . "og-image" }}. Fields of other values, such as the dot inside a
+// range, may be listed too; looking them up on the root is harmless.
+func rootFields(tree *parse.Tree) (names []string, whole bool) {
+	var walk func(node parse.Node, rooted bool)
+	walk = func(node parse.Node, rooted bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, rooted)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, rooted)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg, rooted)
+				}
+			}
+		case *parse.DotNode:
+			if rooted {
+				whole = true
+			}
+		case *parse.FieldNode:
+			names = append(names, n.Ident[0])
+		case *parse.VariableNode:
+			switch {
+			case n.Ident[0] != "$":
+			case len(n.Ident) > 1:
+				names = append(names, n.Ident[1])
+			default:
+				whole = true
+			}
+		case *parse.ChainNode:
+			walk(n.Node, rooted)
+		case *parse.IfNode:
+			walk(n.Pipe, rooted)
+			walk(n.List, rooted)
+			walk(n.ElseList, rooted)
+		case *parse.WithNode:
+			walk(n.Pipe, rooted)
+			walk(n.List, false)
+			walk(n.ElseList, rooted)
+		case *parse.RangeNode:
+			walk(n.Pipe, rooted)
+			walk(n.List, false)
+			walk(n.ElseList, rooted)
+		case *parse.TemplateNode:
+			walk(n.Pipe, rooted)
+		}
+	}
+	if tree != nil {
+		walk(tree.Root, true)
+	}
+	return names, whole
+}
+
+// fieldValue looks name up on data the way a template would: a method
+// without arguments is called, otherwise an exported struct field is read.
+// Methods that take arguments stay reachable through .Root.
+func fieldValue(data any, name string) (any, bool, error) {
+	rv := reflect.ValueOf(data)
+	if !rv.IsValid() {
+		return nil, false, nil
+	}
+	if method := rv.MethodByName(name); method.IsValid() {
+		mt := method.Type()
+		if mt.NumIn() != 0 || mt.NumOut() == 0 || mt.NumOut() > 2 {
+			return nil, false, nil
+		}
+		out := method.Call(nil)
+		if len(out) == 2 && !out[1].IsNil() {
+			if err, ok := out[1].Interface().(error); ok {
+				return nil, false, fmt.Errorf("calling %s: %w", name, err)
+			}
+		}
+		return out[0].Interface(), true, nil
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		if field, ok := rv.Type().FieldByName(name); ok && field.IsExported() {
+			if value, err := rv.FieldByIndexErr(field.Index); err == nil {
+				return value.Interface(), true, nil
+			}
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			if value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())); value.IsValid() {
+				return value.Interface(), true, nil
+			}
+		}
+	}
+	return nil, false, nil
 }
 
 func (h *HC) mergedFuncMap(ctx context.Context) template.FuncMap {
@@ -690,83 +1383,145 @@ func (h *HC) cacheKey(ctx context.Context, componentName string) string {
 }
 
 func (h *HC) getComponentSource(name string) ([]byte, string, error) {
+	src, err := h.lookupComponentSource(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return src.content, src.source, nil
+}
+
+func (h *HC) lookupComponentSource(name string) (componentSource, error) {
 	cacheKey := strings.ToLower(name)
 
 	h.cache.mu.RLock()
 	if src, ok := h.cache.sources[cacheKey]; ok && src.content != nil {
 		h.cache.mu.RUnlock()
-		return src.content, src.source, nil
+		return src, nil
 	}
 	h.cache.mu.RUnlock()
 
 	content, source, err := h.readComponentFile(name)
 	if err != nil {
-		return nil, "", err
+		return componentSource{}, err
 	}
 
-	h.cache.mu.Lock()
-	h.cache.sources[cacheKey] = componentSource{
+	src := componentSource{
 		content: content,
 		source:  source,
+		decl:    parseComponentDecl(content, h.componentDelims(name)),
 	}
+	h.cache.mu.Lock()
+	h.cache.sources[cacheKey] = src
 	h.cache.mu.Unlock()
 
-	return content, source, nil
+	return src, nil
 }
 
 func (h *HC) readFile(name string) ([]byte, error) {
 	if h.cfg.fs != nil {
-		return h.cfg.fs.ReadFile(name)
+		return fs.ReadFile(h.cfg.fs, name)
 	}
 	return os.ReadFile(name)
 }
 
-func (h *HC) renderComponent(state *renderState, elem xml.StartElement, raw []byte, depth int) ([]byte, error) {
-	component := elem.Name.Local
-	start := time.Now()
-	h.emitInstrumentation(state.ctx, component, ComponentStageBegin, nil, 0)
+// renderComponent renders one component invocation, including the
+// components its template emits, between its instrumentation events.
+func (h *HC) renderComponent(state *renderState, tag componentTag) ([]byte, error) {
+	component := tag.Name
+	frame := ComponentFrame{Component: component, Origin: tag.Origin}
+	state = state.push(frame)
+	*state.components++
 
+	event := ComponentInstrumentationEvent{
+		Component: component,
+		Stage:     ComponentStageBegin,
+		Origin:    frame.Origin,
+		Stack:     state.stack,
+		Depth:     len(state.stack),
+		SpanID:    h.spans.Add(1),
+		ParentID:  state.span,
+	}
+	state.span = event.SpanID
+
+	start := time.Now()
+	var (
+		tpl     *template.Template
+		loadErr error
+	)
+	if len(state.stack) <= h.cfg.maxDepth {
+		tpl, event.Source, event.Cached, loadErr = h.loadComponentTemplate(state, component)
+	}
+	h.emitInstrumentation(state.ctx, event)
+
+	// props is filled in once the attributes are resolved so failures after
+	// that point can report them.
+	var props map[string]any
 	var execErr error
 	defer func() {
-		h.emitInstrumentation(state.ctx, component, ComponentStageEnd, execErr, time.Since(start))
+		event.Stage = ComponentStageEnd
+		event.Err = execErr
+		event.Duration = time.Since(start)
+		event.PropsCount = len(props)
+		h.emitInstrumentation(state.ctx, event)
 	}()
 
-	if depth > maxComponentPasses {
-		execErr = fmt.Errorf("component rendering exceeded %d passes", maxComponentPasses)
+	fail := func(err error) ([]byte, error) {
+		var compErr *ComponentError
+		if errors.As(err, &compErr) {
+			execErr = err
+		} else {
+			execErr = &ComponentError{Component: component, Origin: frame.Origin, Stack: state.stack, Props: props, Err: err}
+		}
 		return nil, execErr
 	}
 
-	tpl, err := h.loadComponentTemplate(state, component)
-	if err != nil {
-		execErr = err
-		return nil, err
+	if len(state.stack) > h.cfg.maxDepth {
+		return fail(fmt.Errorf("component nesting exceeded max depth %d: %s", h.cfg.maxDepth, stackPath(state.stack)))
 	}
-
-	children, selfClosing, err := splitComponentBody(raw, component)
-	if err != nil {
-		execErr = err
-		return nil, err
+	if loadErr != nil {
+		return fail(loadErr)
 	}
 
-	renderedChildren := template.HTML("")
-	if len(children) > 0 {
-		childOutput, err2 := h.renderMarkupBytes(state, children, depth+1)
-		if err2 != nil {
-			execErr = err2
-			return nil, err2
+	children := tag.Children
+	var resolved []resolvedAttr
+	if tag.props != nil {
+		props, resolved = presetAttrs(tag.props)
+	} else {
+		var err error
+		if props, resolved, err = h.resolveAttrs(state, tag.Attrs); err != nil {
+			return fail(fmt.Errorf("component %s %w", component, err))
 		}
-		renderedChildren = template.HTML(string(childOutput))
 	}
 
-	props, resolved, err := h.resolveAttrs(state, elem.Attr)
-	if err != nil {
-		execErr = err
-		return nil, err
+	resolved = h.applyDeprecations(state, frame, props, resolved)
+	h.inferAttrs(component, props, resolved)
+	if err := h.validateAttributes(state, frame, props, resolved); err != nil {
+		return fail(err)
 	}
 
-	if err := h.validateAttributes(component, props); err != nil {
-		execErr = err
-		return nil, err
+	if cycle := state.findCycle(component, props, children); cycle != nil {
+		return fail(fmt.Errorf("component cycle: %s", stackPath(cycle)))
+	}
+	state.active = append(slices.Clip(state.active), activeComponent{props: props, children: children})
+
+	// Children render after the template so they can see the values it
+	// provides; until then the template receives a placeholder.
+	placeholder := template.HTML("")
+	if len(children) > 0 {
+		placeholder = template.HTML(state.childrenMarker)
+	}
+	childrenRaw := children
+	if len(state.enclosingChildren) > 0 && bytes.Contains(children, []byte(state.childrenMarker)) {
+		childrenRaw = bytes.ReplaceAll(children, []byte(state.childrenMarker), state.enclosingChildren)
+	}
+
+	inherited := state.provided
+	if inherited == nil {
+		inherited = map[string]any{}
+	}
+	parent := state.parent
+	if parent == nil {
+		parent = map[string]any{}
 	}
 
 	payload := map[string]any{
@@ -775,36 +1530,89 @@ func (h *HC) renderComponent(state *renderState, elem xml.StartElement, raw []by
 		"Ctx":         state.ctx,
 		"Data":        state.data,
 		"Root":        state.data,
+		"Parent":      parent,
+		"Context":     inherited,
+		"Provide":     map[string]any{},
 		"Component":   component,
 		"HasChildren": len(children) > 0,
-		"ChildrenRaw": string(children),
-		"Children":    renderedChildren,
-		"SelfClosing": selfClosing,
+		"ChildrenRaw": string(childrenRaw),
+		"Children":    placeholder,
+		"SelfClosing": tag.SelfClosing,
 	}
 
 	if err := h.applyComponentAugmenters(state, component, payload); err != nil {
-		execErr = err
-		return nil, err
+		return fail(fmt.Errorf("augment component %s: %w", component, err))
+	}
+	if h.diagnosing(state.ctx) {
+		h.checkUsage(state, frame, props, children)
+	}
+	if augmented, ok := payload["Props"].(map[string]any); ok {
+		payload["Props"] = h.strictProps(component, augmented)
 	}
 
 	var buf bytes.Buffer
 	if err := tpl.Execute(&buf, payload); err != nil {
-		execErr = fmt.Errorf("render component %s: %w", component, err)
-		return nil, execErr
+		return fail(fmt.Errorf("render component %s: %w", component, err))
 	}
 
-	return buf.Bytes(), nil
+	inner := state.withProvided(payload["Provide"]).withParent(props)
+
+	// Expand the components the template emits now, so their spans nest
+	// inside this one and Duration and Bytes cover the whole subtree.
+	expand := inner.withSource(Origin{File: event.Source})
+	expand.enclosingChildren = childrenRaw
+	expand.emitted = true
+	output, err := h.renderMarkupBytes(expand, buf.Bytes())
+	if err != nil {
+		return fail(err)
+	}
+
+	// Only now is the output final: attribute values of the components
+	// just expanded had to be evaluated with their delimiters intact.
+	// Children are spliced in afterwards because actions in page markup
+	// are meant for the final pass.
+	if state.delimMarker != "" {
+		output = h.neutralizeDelims(state, output)
+	}
+
+	if len(children) > 0 && bytes.Contains(output, []byte(state.childrenMarker)) {
+		renderedChildren, err := h.renderMarkupBytes(inner.withSource(tag.ChildrenOrigin), children)
+		if err != nil {
+			return fail(err)
+		}
+		output = bytes.ReplaceAll(output, []byte(state.childrenMarker), renderedChildren)
+	}
+
+	event.Bytes = len(output)
+	return output, nil
 }
 
-func (h *HC) resolveAttrs(state *renderState, attrs []xml.Attr) (map[string]any, []resolvedAttr, error) {
+func (h *HC) resolveAttrs(state *renderState, attrs []markup.Attr) (map[string]any, []resolvedAttr, error) {
 	props := make(map[string]any, len(attrs))
 	resolved := make([]resolvedAttr, 0, len(attrs))
 
+	var spread []resolvedAttr
 	for _, attr := range attrs {
-		name := attr.Name.Local
-		value, err := h.evaluateAttr(state, attr.Value)
-		if err != nil {
-			return nil, nil, fmt.Errorf("attr %s: %w", name, err)
+		name := attr.Name
+		if isSpreadAttr(name) {
+			entries, err := h.spreadAttrs(state, attr.Value, attr.HasValue)
+			if err != nil {
+				return nil, nil, fmt.Errorf("attr %s: %w", name, err)
+			}
+			spread = entries
+			continue
+		}
+		// Valueless attributes such as <Button disabled> are boolean flags.
+		var value any = true
+		switch {
+		case attr.HasValue && state.emitted:
+			value = interpretAttrValue(attr.Value)
+		case attr.HasValue:
+			var err error
+			value, err = h.evaluateAttr(state, attr.Value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("attr %s: %w", name, err)
+			}
 		}
 		canonical := strings.ToLower(name)
 		props[canonical] = value
@@ -814,36 +1622,107 @@ func (h *HC) resolveAttrs(state *renderState, attrs []xml.Attr) (map[string]any,
 			Value:     value,
 		})
 	}
+	if len(spread) > 0 {
+		resolved = mergeSpread(props, resolved, spread)
+	}
 	return props, resolved, nil
 }
 
+// presetAttrs converts props supplied from Go into the form resolveAttrs
+// produces, in name order.
+func presetAttrs(values map[string]any) (map[string]any, []resolvedAttr) {
+	props := make(map[string]any, len(values))
+	resolved := make([]resolvedAttr, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		canonical := strings.ToLower(name)
+		props[canonical] = values[name]
+		resolved = append(resolved, resolvedAttr{Name: name, Canonical: canonical, Value: values[name]})
+	}
+	return props, resolved
+}
+
 func (h *HC) evaluateAttr(state *renderState, raw string) (any, error) {
 	if strings.TrimSpace(raw) == "" {
 		return "", nil
 	}
-
-	funcs := texttmpl.FuncMap{}
-	if len(state.funcs) > 0 {
-		funcs = make(texttmpl.FuncMap, len(state.funcs))
-		for name, fn := range state.funcs {
-			funcs[name] = fn
-		}
+	if !strings.Contains(raw, h.cfg.delims.left) {
+		return interpretAttrValue(raw), nil
 	}
 
-	tpl, err := texttmpl.New("attr").Funcs(funcs).Option("missingkey=zero").Parse(raw)
+	tpl, err := h.attrTemplate(state, raw)
 	if err != nil {
 		return "", err
 	}
 
+	scope, err := state.scopeData(tpl)
+	if err != nil {
+		return "", err
+	}
 	var buf bytes.Buffer
-	if err := tpl.Execute(&buf, state.data); err != nil {
+	if err := tpl.Execute(&buf, scope); err != nil {
 		return "", err
 	}
 
 	return interpretAttrValue(buf.String()), nil
 }
 
-func (h *HC) loadComponentTemplate(state *renderState, name string) (*template.Template, error) {
+// attrTemplate returns the parsed expression for raw, reusing earlier parses.
+func (h *HC) attrTemplate(state *renderState, raw string) (*texttmpl.Template, error) {
+	return h.cachedTextTemplate(state, raw, func() (*texttmpl.Template, error) {
+		return parseAttrTemplate(raw, state.funcs, h.cfg.delims, h.strictKeys(""))
+	})
+}
+
+// cachedTextTemplate memoizes text templates under key. Templates are shared
+// across renders unless a func map provider is set, in which case they only
+// live for the current render.
+func (h *HC) cachedTextTemplate(state *renderState, key string, parse func() (*texttmpl.Template, error)) (*texttmpl.Template, error) {
+	if h.cfg.funcMapProvider != nil {
+		if tpl, ok := state.attrs[key]; ok {
+			return tpl, nil
+		}
+		tpl, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		if state.attrs != nil {
+			state.attrs[key] = tpl
+		}
+		return tpl, nil
+	}
+
+	h.cache.mu.RLock()
+	tpl, ok := h.cache.attrs[key]
+	h.cache.mu.RUnlock()
+	if ok {
+		return tpl, nil
+	}
+
+	tpl, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	h.cache.mu.Lock()
+	if len(h.cache.attrs) < maxAttrCacheEntries {
+		h.cache.attrs[key] = tpl
+	}
+	h.cache.mu.Unlock()
+
+	return tpl, nil
+}
+
+func parseAttrTemplate(raw string, funcs template.FuncMap, d delims, strict bool) (*texttmpl.Template, error) {
+	textFuncs := make(texttmpl.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		textFuncs[name] = fn
+	}
+	return texttmpl.New("attr").Delims(d.left, d.right).Funcs(textFuncs).Option(missingKeyOption(strict)).Parse(raw)
+}
+
+// loadComponentTemplate returns the parsed template of a component, its
+// source file, and whether it came from the cache.
+func (h *HC) loadComponentTemplate(state *renderState, name string) (*template.Template, string, bool, error) {
 	key := h.cacheKey(state.ctx, name)
 	provider := h.cfg.funcMapProvider
 
@@ -851,18 +1730,19 @@ func (h *HC) loadComponentTemplate(state *renderState, name string) (*template.T
 		h.cache.mu.RLock()
 		if entry, ok := h.cache.entries[key]; ok && entry.tpl != nil {
 			h.cache.mu.RUnlock()
-			return entry.tpl, nil
+			return entry.tpl, entry.source, true, nil
 		}
 		h.cache.mu.RUnlock()
 	}
 
 	content, source, err := h.getComponentSource(name)
 	if err != nil {
-		return nil, err
+		return nil, "", false, err
 	}
 
 	funcs := h.componentFuncMap(state.funcs)
-	tpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(string(content))
+	d := h.componentDelims(name)
+	tpl, err := template.New(name).Delims(d.left, d.right).Funcs(funcs).Option(missingKeyOption(h.strictKeys(name))).Parse(string(content))
 	if err != nil {
 		if tmplErr, ok := err.(*template.Error); ok {
 			location := source
@@ -870,23 +1750,23 @@ func (h *HC) loadComponentTemplate(state *renderState, name string) (*template.T
 				location = name
 			}
 			if tmplErr.Line > 0 {
-				return nil, fmt.Errorf("parse component %s (%s:%d): %s", name, location, tmplErr.Line, tmplErr.Description)
+				return nil, "", false, fmt.Errorf("parse component %s (%s:%d): %s", name, location, tmplErr.Line, tmplErr.Description)
 			}
-			return nil, fmt.Errorf("parse component %s (%s): %s", name, location, tmplErr.Description)
+			return nil, "", false, fmt.Errorf("parse component %s (%s): %s", name, location, tmplErr.Description)
 		}
 		if source != "" {
-			return nil, fmt.Errorf("parse component %s (%s): %w", name, source, err)
+			return nil, "", false, fmt.Errorf("parse component %s (%s): %w", name, source, err)
 		}
-		return nil, fmt.Errorf("parse component %s: %w", name, err)
+		return nil, "", false, fmt.Errorf("parse component %s: %w", name, err)
 	}
 
 	if provider == nil {
 		h.cache.mu.Lock()
-		h.cache.entries[key] = cacheEntry{tpl: tpl}
+		h.cache.entries[key] = cacheEntry{tpl: tpl, source: source}
 		h.cache.mu.Unlock()
 	}
 
-	return tpl, nil
+	return tpl, source, false, nil
 }
 
 func (h *HC) componentFuncMap(funcs template.FuncMap) template.FuncMap {
@@ -894,7 +1774,15 @@ func (h *HC) componentFuncMap(funcs template.FuncMap) template.FuncMap {
 	for name, fn := range funcs {
 		merged[name] = fn
 	}
-	merged["forwardAttrs"] = forwardAttrs
+	merged["forwardAttrs"] = h.forwardAttrs
+	merged["mergeAttrs"] = h.mergeAttrs
+	// Applications often bring their own dict, such as sprig's, or a
+	// provide of their own; keep theirs.
+	for name, fn := range map[string]any{"classNames": classNames, "dict": dict, "provide": provide} {
+		if _, ok := merged[name]; !ok {
+			merged[name] = fn
+		}
+	}
 	return merged
 }
 
@@ -904,7 +1792,7 @@ func (h *HC) readComponentFile(name string) ([]byte, string, error) {
 		if h.cfg.fs != nil {
 			paths := uniqueFSPaths(h.folder, candidate)
 			for _, p := range paths {
-				data, err := h.cfg.fs.ReadFile(p)
+				data, err := fs.ReadFile(h.cfg.fs, p)
 				if err == nil {
 					return data, p, nil
 				}
@@ -913,7 +1801,7 @@ func (h *HC) readComponentFile(name string) ([]byte, string, error) {
 			continue
 		}
 
-		// If no embed FS is configured read from the host filesystem.
+		// If no FS is configured read from the host filesystem.
 		fullPath := filepath.Join(h.folder, candidate)
 		data, err := os.ReadFile(fullPath)
 		if err == nil {
@@ -940,47 +1828,13 @@ type resolvedAttr struct {
 	Value any
 }
 
-// splitComponentBody separates child markup from the outer tag and detects self-closing tags.
-func splitComponentBody(raw []byte, name string) ([]byte, bool, error) {
-	// openEnd locates the closing bracket of the start tag.
-	openEnd := bytes.IndexByte(raw, '>')
-	if openEnd == -1 {
-		return nil, false, fmt.Errorf("component %s has no closing bracket", name)
-	}
-
-	// selfClosing becomes true when the tag ends with "/>".
-	selfClosing := false
-	for i := openEnd - 1; i >= 0; i-- {
-		if raw[i] == '/' {
-			selfClosing = true
-			break
-		}
-		if !isSpaceByte(raw[i]) {
-			break
-		}
-	}
-
-	if selfClosing {
-		return nil, true, nil
-	}
-
-	closeToken := "</" + name
-	closeIdx := bytes.LastIndex(raw, []byte(closeToken))
-	if closeIdx == -1 {
-		return nil, false, fmt.Errorf("component %s missing closing tag", name)
-	}
-
-	children := raw[openEnd+1 : closeIdx]
-	return children, false, nil
-}
-
 func componentFileCandidates(name string) []string {
 	var candidates []string
 	seen := make(map[string]struct{})
 
 	lower := strings.ToLower(name)
 	kebab := toKebabCase(name)
-	basenames := []string{name, lower, kebab}
+	basenames := []string{name, lower, kebab, strings.ReplaceAll(kebab, "-", "_")}
 	exts := []string{".gohtml", ".tmpl", ".html"}
 
 	for _, base := range basenames {
@@ -1041,7 +1895,12 @@ func interpretAttrValue(raw string) any {
 	return raw
 }
 
-func forwardAttrs(attrs []resolvedAttr, exclude ...string) template.HTMLAttr {
+// forwardAttrs renders attrs as HTML attributes, leaving out the names in
+// exclude. Like html/template it filters what it forwards: attributes with
+// invalid names are dropped, event handlers are dropped unless allowed with
+// WithEventAttrs or passed as template.JS, and URL attributes with unsafe
+// schemes are replaced by "#ZgotmplZ" unless passed as template.URL.
+func (h *HC) forwardAttrs(attrs []resolvedAttr, exclude ...string) template.HTMLAttr {
 	if len(attrs) == 0 {
 		return ""
 	}
@@ -1059,6 +1918,9 @@ func forwardAttrs(attrs []resolvedAttr, exclude ...string) template.HTMLAttr {
 		if _, ok := skip[attr.Canonical]; ok {
 			continue
 		}
+		if !validAttrName(attr.Name) {
+			continue
+		}
 
 		switch v := attr.Value.(type) {
 		case nil:
@@ -1069,8 +1931,8 @@ func forwardAttrs(attrs []resolvedAttr, exclude ...string) template.HTMLAttr {
 				buf.WriteString(html.EscapeString(attr.Name))
 			}
 		default:
-			str := fmt.Sprint(v)
-			if str == "" {
+			str, ok := h.filterAttrValue(attr.Canonical, v)
+			if !ok || str == "" {
 				continue
 			}
 			buf.WriteByte(' ')
@@ -1083,6 +1945,21 @@ func forwardAttrs(attrs []resolvedAttr, exclude ...string) template.HTMLAttr {
 	return template.HTMLAttr(buf.String())
 }
 
+// provide publishes value under key for the descendants of the component
+// whose payload is passed in, typically as {{ provide $ "key" value }}.
+func provide(payload map[string]any, key string, value any) string {
+	if payload == nil || key == "" {
+		return ""
+	}
+	published, ok := payload["Provide"].(map[string]any)
+	if !ok {
+		published = map[string]any{}
+		payload["Provide"] = published
+	}
+	published[key] = value
+	return ""
+}
+
 func toKebabCase(name string) string {
 	if name == "" {
 		return ""
@@ -1114,7 +1991,3 @@ func toKebabCase(name string) string {
 	}
 	return strings.Trim(b.String(), "-")
 }
-
-func isSpaceByte(b byte) bool {
-	return b == ' ' || b == '\n' || b == '\r' || b == '\t'
-}
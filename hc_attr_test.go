@@ -0,0 +1,312 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateAttr_CachesParsedExpressions(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/button.html", `<button>{{ .Props.text }}</button>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", strings.Repeat(`<Button text="{{ upper .Label }}" class="primary" />`, 3))
+
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithFuncMap(template.FuncMap{"upper": strings.ToUpper}),
+	)
+
+	for _, label := range []string{"save", "send"} {
+		var buf bytes.Buffer
+		if err := engine.ParseFileContext(context.Background(), &buf, pagePath, map[string]any{"Label": label}); err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+		want := strings.Repeat("<button>"+strings.ToUpper(label)+"</button>", 3)
+		if got := buf.String(); got != want {
+			t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+		}
+	}
+
+	engine.cache.mu.RLock()
+	defer engine.cache.mu.RUnlock()
+	if got := len(engine.cache.attrs); got != 1 {
+		t.Fatalf("expected 1 cached attribute expression (static values skip templating), got %d", got)
+	}
+	if _, ok := engine.cache.attrs[`{{ upper .Label }}`]; !ok {
+		t.Fatalf("attribute expression missing from cache")
+	}
+}
+
+func TestEvaluateAttr_ProviderUsesPerRenderCache(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/greet.html", `<p>{{ .Props.text }}</p>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Greet text="{{ who }}" /><Greet text="{{ who }}" />`)
+
+	type nameKey struct{}
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithFuncMapProvider(func(ctx context.Context) template.FuncMap {
+			name, _ := ctx.Value(nameKey{}).(string)
+			return template.FuncMap{"who": func() string { return name }}
+		}),
+	)
+
+	for _, name := range []string{"ada", "linus"} {
+		var buf bytes.Buffer
+		ctx := context.WithValue(context.Background(), nameKey{}, name)
+		if err := engine.ParseFileContext(ctx, &buf, pagePath, nil); err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+		want := "<p>" + name + "</p><p>" + name + "</p>"
+		if got := buf.String(); got != want {
+			t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+		}
+	}
+
+	engine.cache.mu.RLock()
+	defer engine.cache.mu.RUnlock()
+	if got := len(engine.cache.attrs); got != 0 {
+		t.Fatalf("provider-bound expressions must not enter the shared cache; got %d entries", got)
+	}
+}
+
+func BenchmarkEvaluateAttr(b *testing.B) {
+	engine := NewHC("", WithFuncMap(template.FuncMap{"upper": strings.ToUpper}))
+	state := &renderState{
+		ctx:   context.Background(),
+		funcs: engine.mergedFuncMap(context.Background()),
+		data:  map[string]any{"Label": "save changes"},
+	}
+	const dynamic = `{{ upper .Label }}`
+
+	b.Run("static", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := engine.evaluateAttr(state, "primary"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := engine.evaluateAttr(state, dynamic); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			tpl, err := parseAttrTemplate(dynamic, state.funcs, defaultDelims, false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := tpl.Execute(&buf, state.data); err != nil {
+				b.Fatal(err)
+			}
+			interpretAttrValue(buf.String())
+		}
+	})
+}
+
+func TestForwardAttrs_FiltersUnsafeAttributes(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/link.html", `<a{{ forwardAttrs .Attrs }}>x</a>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Link href="{{ .URL }}" onclick="steal()" onmouseover="track()" data-src="javascript:x" title="a:b" srcset="/a.png 1x, javascript:x 2x" />`)
+
+	var buf bytes.Buffer
+	engine := NewHC(filepath.Join(tmp, "components"), WithEventAttrs("onmouseover"))
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, map[string]any{"URL": "JavaScript:alert(1)"}); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<a href="#ZgotmplZ" onmouseover="track()" data-src="#ZgotmplZ" title="a:b" srcset="/a.png 1x, #ZgotmplZ">x</a>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestForwardAttrs_TrustsTypedValues(t *testing.T) {
+	t.Parallel()
+
+	engine := NewHC("components")
+	got := engine.forwardAttrs([]resolvedAttr{
+		{Name: "href", Canonical: "href", Value: template.URL("tel:+123")},
+		{Name: "onclick", Canonical: "onclick", Value: template.JS("go()")},
+		{Name: "src", Canonical: "src", Value: "https://example.com/a.png"},
+		{Name: `x"y`, Canonical: `x"y`, Value: "z"},
+	})
+	if want := ` href="tel:+123" onclick="go()" src="https://example.com/a.png"`; string(got) != want {
+		t.Fatalf("forwardAttrs = %q, want %q", got, want)
+	}
+}
+
+func TestAttrRules_TypedValues(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/pager.html", `<nav data-size="{{ .Props.size }}">{{ add .Props.page 1 }}/{{ .Props.pages }} {{ if .Props.compact }}compact{{ end }} {{ printf "%.1f" .Props.ratio }}</nav>`)
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithFuncMap(template.FuncMap{"add": func(a, b int) int { return a + b }}),
+		WithAttrRules("Pager",
+			RequireAttrs("page"),
+			AttrType("page", Int),
+			AttrType("pages", Int),
+			AttrValidator("pages", func(value any) error {
+				if value.(int) > 100 {
+					return errors.New("must be at most 100")
+				}
+				return nil
+			}),
+			AttrType("compact", Bool),
+			AttrType("ratio", Float),
+			AttrEnum("size", "sm", "md", "lg"),
+			AttrDefault("size", "md"),
+			AttrPattern("id", regexp.MustCompile(`^[a-z][a-z0-9-]*$`)),
+			AttrExclusive("compact", "wide"),
+			AttrTogether("prev", "next"),
+		),
+	)
+
+	render := func(page string) (string, error) {
+		pagePath := writeTestFile(t, tmp, "pages/page.gohtml", page)
+		var buf bytes.Buffer
+		err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil)
+		return buf.String(), err
+	}
+
+	got, err := render(`<Pager page="2" pages="9" compact ratio="0.25" />`)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if want := `<nav data-size="md">3/9 compact 0.2</nav>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+
+	for page, want := range map[string]string{
+		`<Pager page="two" />`:                   `component Pager attr "page": must be an int, got "two"`,
+		`<Pager page="1" pages="500" />`:         `component Pager attr "pages": must be at most 100`,
+		`<Pager page="1" size="xl" />`:           `component Pager attr "size": must be one of "sm", "md", "lg", got "xl"`,
+		`<Pager page="1" id="Main" />`:           `component Pager attr "id": must match ^[a-z][a-z0-9-]*$, got "Main"`,
+		`<Pager page="1" compact wide />`:        `component Pager attrs "compact", "wide" are mutually exclusive`,
+		`<Pager page="1" prev="/1" />`:           `component Pager attr "prev" requires "next"`,
+		`<Pager page="1" compact="sometimes" />`: `component Pager attr "compact": must be a bool, got "sometimes"`,
+	} {
+		if _, err := render(page); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", page, want, err)
+		}
+	}
+}
+
+func TestAttrType_JSONAndDurations(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/select.html", "{{/* @props options!:json count:int delay:duration */}}\n<select data-count=\"{{ if eq .Props.count 3 }}three{{ end }}\" data-delay=\"{{ .Props.delay.Milliseconds }}\">{{ range .Props.options }}<option>{{ . }}</option>{{ end }}</select>")
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Select options='["a","b"]' count="3" delay="1.5s" />`)
+	badPage := writeTestFile(t, tmp, "pages/bad.gohtml", `<Select options='["a",' />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, nil); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := "\n<select data-count=\"three\" data-delay=\"1500\"><option>a</option><option>b</option></select>"
+	if out.String() != want {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	err := engine.ParseFileContext(context.Background(), nil, badPage, nil)
+	if err == nil || !strings.Contains(err.Error(), `component Select attr "options": must be JSON`) {
+		t.Fatalf("expected JSON error, got %v", err)
+	}
+}
+
+func TestInferredAttrs_ConvertNumbersAndJSON(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/stat.html", `{{ printf "%T %T %T %T %T" .Props.count .Props.ratio .Props.tags .Props.zip .Props.label }}`)
+	writeTestFile(t, tmp, "components/plain.html", `{{ printf "%T" .Props.count }}`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Stat count="{{ .N }}" ratio="0.5" tags='["x"]' zip="02134" label="[draft" />|<Plain count="3" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithInferredAttrs("Stat"))
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, map[string]any{"N": 42}); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got, want := out.String(), "int float64 []interface {} string string|string"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestCoerceAttr_ErrorNamesKind(t *testing.T) {
+	t.Parallel()
+
+	for kind, want := range map[AttrKind]string{
+		Int:   "must be an int, got []string",
+		Float: "must be a float, got []string",
+		Bool:  "must be a bool, got []string",
+	} {
+		if _, err := coerceAttr([]string{"x"}, kind); err == nil || err.Error() != want {
+			t.Errorf("%s: expected %q, got %v", kind, want, err)
+		}
+	}
+}
+
+func TestComponents_DescribeValueRules(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/pager.html", `<nav></nav>`)
+	writeTestFile(t, tmp, "components/select.html", "{{/* @props options!:json delay:duration */}}<select></select>")
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithAttrRules("Pager",
+			AttrType("page", Int),
+			AttrEnum("size", "sm", "md"),
+			AttrDefault("size", "md"),
+			AttrPattern("id", regexp.MustCompile(`^[a-z]+$`)),
+			AttrExclusive("compact", "wide"),
+			AttrTogether("prev", "next"),
+		),
+	)
+
+	infos, err := engine.Components()
+	if err != nil {
+		t.Fatalf("Components: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(infos))
+	}
+
+	pager := infos[0].Rules
+	switch {
+	case pager.Types["page"] != Int:
+		t.Errorf("Types = %v", pager.Types)
+	case !reflect.DeepEqual(pager.Enums["size"], []string{"sm", "md"}):
+		t.Errorf("Enums = %v", pager.Enums)
+	case pager.Defaults["size"] != "md":
+		t.Errorf("Defaults = %v", pager.Defaults)
+	case pager.Patterns["id"] != `^[a-z]+$`:
+		t.Errorf("Patterns = %v", pager.Patterns)
+	case !reflect.DeepEqual(pager.Exclusive, [][]string{{"compact", "wide"}}):
+		t.Errorf("Exclusive = %v", pager.Exclusive)
+	case !reflect.DeepEqual(pager.Together, [][]string{{"prev", "next"}}):
+		t.Errorf("Together = %v", pager.Together)
+	}
+
+	sel := infos[1].Rules
+	if sel.Types["options"] != JSON || sel.Types["delay"] != Duration || !reflect.DeepEqual(sel.Required, []string{"options"}) {
+		t.Errorf("unexpected @props rules: %+v", sel)
+	}
+}
@@ -0,0 +1,125 @@
+package hc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuild_RendersRoutesAssetsAndSitemap(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/hero.html", `<h1>{{ .Props.title }}</h1>`)
+	home := writeTestFile(t, tmp, "pages/home.gohtml", `<Hero title="{{ .Title }}" />`)
+	list := writeTestFile(t, tmp, "pages/list.gohtml", `<For each=".Plans" as="plan"><Hero title="{{ .plan.name }}" /></For>`)
+	jsonFixture := writeTestFile(t, tmp, "fixtures/about.json", `{"Title": "About us"}`)
+	yamlFixture := writeTestFile(t, tmp, "fixtures/pricing.yaml", "Plans:\n  - name: Free\n  - name: Pro\n")
+	writeTestFile(t, tmp, "static/css/site.css", `body{}`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	out := filepath.Join(tmp, "dist")
+	routes := []Route{
+		{Path: "/", Page: home, Data: map[string]any{"Title": "Home"}},
+		{Path: "/about", Page: home, Fixture: jsonFixture},
+		{Path: "/pricing/", Page: list, Fixture: yamlFixture},
+		{Path: "/blog/first", Page: home, Loader: func(ctx context.Context) (any, error) {
+			return map[string]any{"Title": "First post"}, nil
+		}},
+		{Path: "/404.html", Page: home, Data: map[string]any{"Title": "Not found"}},
+	}
+	err := engine.Build(context.Background(), out, routes,
+		BuildAssets(filepath.Join(tmp, "static"), "static"),
+		BuildBaseURL("https://example.com/"),
+		BuildConcurrency(2),
+	)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"index.html":            "<h1>Home</h1>",
+		"about/index.html":      "<h1>About us</h1>",
+		"pricing/index.html":    "<h1>Free</h1><h1>Pro</h1>",
+		"blog/first/index.html": "<h1>First post</h1>",
+		"404.html":              "<h1>Not found</h1>",
+		"static/css/site.css":   "body{}",
+	} {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("read sitemap: %v", err)
+	}
+	for _, loc := range []string{
+		"<loc>https://example.com/</loc>",
+		"<loc>https://example.com/about/</loc>",
+		"<loc>https://example.com/pricing/</loc>",
+	} {
+		if !strings.Contains(string(sitemap), loc) {
+			t.Errorf("sitemap missing %s:\n%s", loc, sitemap)
+		}
+	}
+	if strings.Contains(string(sitemap), "404.html") {
+		t.Errorf("sitemap lists the 404 page:\n%s", sitemap)
+	}
+}
+
+func TestBuild_SkipsSitemapWithoutBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	home := writeTestFile(t, tmp, "pages/home.gohtml", `<h1>Home</h1>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	out := filepath.Join(tmp, "dist")
+	if err := engine.Build(context.Background(), out, []Route{{Path: "/", Page: home}}); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err != nil {
+		t.Fatalf("expected the page to be written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "sitemap.xml")); !os.IsNotExist(err) {
+		t.Fatalf("expected no sitemap without a base URL, got %v", err)
+	}
+}
+
+func TestBuild_ReportsEveryFailingRoute(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/hero.html", `<h1>{{ .Props.title }}</h1>`)
+	good := writeTestFile(t, tmp, "pages/good.gohtml", `<Hero title="ok" />`)
+	bad := writeTestFile(t, tmp, "pages/bad.gohtml", `<Missing />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	err := engine.Build(context.Background(), filepath.Join(tmp, "dist"), []Route{
+		{Path: "/a", Page: bad},
+		{Path: "/b", Page: good},
+		{Path: "/c", Page: good, Fixture: filepath.Join(tmp, "missing.json")},
+	})
+	if err == nil {
+		t.Fatal("expected build error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "build /a: ") || !strings.Contains(msg, "build /c: ") || strings.Contains(msg, "build /b") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = engine.Build(context.Background(), filepath.Join(tmp, "dist"), []Route{
+		{Path: "/a", Page: good},
+		{Path: "/a/", Page: good},
+	})
+	if err == nil || !strings.Contains(err.Error(), "both write a/index.html") {
+		t.Fatalf("expected duplicate route error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func renderBuiltinPage(t *testing.T, page string, data any) (string, error) {
+	t.Helper()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/item.html", `<li>{{ .Props.label }}</li>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", page)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	var buf bytes.Buffer
+	err := engine.ParseFileContext(context.Background(), &buf, pagePath, data)
+	return buf.String(), err
+}
+
+func TestBuiltinFor_ExposesLoopVariablesToAttributes(t *testing.T) {
+	t.Parallel()
+
+	page := `<ul><For each=".Items" as="item" index="i"><Item label="{{ .i }}:{{ .item.Name }} of {{ .Title }}" /></For></ul>`
+	data := map[string]any{
+		"Title": "list",
+		"Items": []map[string]string{{"Name": "a"}, {"Name": "b"}},
+	}
+
+	got, err := renderBuiltinPage(t, page, data)
+	if err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := "<ul><li>0:a of list</li><li>1:b of list</li></ul>"
+	if got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestBuiltinFor_StructRootFieldsStayReachable(t *testing.T) {
+	t.Parallel()
+
+	page := `<ul><For each=".Items"><Item label="{{ .Title }}-{{ .item }}" /></For></ul>`
+	data := struct {
+		Title string
+		Items []string
+	}{Title: "T", Items: []string{"a", "b"}}
+
+	for _, d := range []any{data, &data} {
+		got, err := renderBuiltinPage(t, page, d)
+		if err != nil {
+			t.Fatalf("ParseFileContext(%T): %v", d, err)
+		}
+		if want := "<ul><li>T-a</li><li>T-b</li></ul>"; got != want {
+			t.Fatalf("rendered output mismatch for %T\nwant: %q\ngot:  %q", d, want, got)
+		}
+	}
+}
+
+func TestBuiltinFor_NestedLoopsAndElse(t *testing.T) {
+	t.Parallel()
+
+	page := `<For each="{{ .Groups }}" as="group"><For each=".group" as="n"><Item label="{{ .n }}" /></For></For>` +
+		`<For each=".Empty"><Item label="never" /></For><Else><Item label="empty" /></Else>`
+	data := map[string]any{
+		"Groups": map[string][]int{"b": {3}, "a": {1, 2}},
+		"Empty":  []string{},
+	}
+
+	got, err := renderBuiltinPage(t, page, data)
+	if err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := "<li>1</li><li>2</li><li>3</li><li>empty</li>"
+	if got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestBuiltinIf_ElseIfElse(t *testing.T) {
+	t.Parallel()
+
+	page := `<If cond=".Admin"><Item label="admin" /></If>
+<ElseIf cond="eq .Role &quot;editor&quot;"><Item label="editor" /></ElseIf>
+<Else><Item label="guest" /></Else>`
+
+	cases := map[string]map[string]any{
+		"<li>admin</li>":  {"Admin": true, "Role": "editor"},
+		"<li>editor</li>": {"Admin": false, "Role": "editor"},
+		"<li>guest</li>":  {"Role": "viewer"},
+	}
+	for want, data := range cases {
+		got, err := renderBuiltinPage(t, page, data)
+		if err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+		if got = strings.TrimSpace(got); got != want {
+			t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+		}
+	}
+}
+
+func TestBuiltinSwitch_MatchesCaseOrDefault(t *testing.T) {
+	t.Parallel()
+
+	page := `<Switch value=".Status">
+  <Case value="active"><Item label="on" /></Case>
+  <Case value="paused"><Item label="hold" /></Case>
+  <Default><Item label="off" /></Default>
+</Switch>`
+
+	for status, want := range map[string]string{"active": "<li>on</li>", "paused": "<li>hold</li>", "gone": "<li>off</li>"} {
+		got, err := renderBuiltinPage(t, page, map[string]any{"Status": status})
+		if err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+		if got != want {
+			t.Fatalf("status %s: rendered output mismatch\nwant: %q\ngot:  %q", status, want, got)
+		}
+	}
+}
+
+func TestBuiltinErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		`<Else>x</Else>`:                       "<Else> must follow",
+		`<Case value="a">x</Case>`:             "direct child of <Switch>",
+		`<For as="x">y</For>`:                  `requires an "each" attribute`,
+		`<For each=".Name">y</For>`:            "cannot range over string",
+		`<Switch value=".A"><Item /></Switch>`: "only accepts <Case> and <Default>",
+	}
+	for page, want := range cases {
+		_, err := renderBuiltinPage(t, page, map[string]any{"Name": "x"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("page %q: expected error containing %q, got %v", page, want, err)
+		}
+	}
+}
+
+func TestBuiltins_ComponentFilesWin(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/switch.html", `<button role="switch" aria-checked="{{ .Props.on }}">{{ .Children }}</button>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Switch on="true">Dark mode</Switch><If cond=".Show">shown</If>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, map[string]any{"Show": true}); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<button role="switch" aria-checked="true">Dark mode</button>shown`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestBuiltinExpr_ConcurrentRendersKeepTheirValues(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/item.html", `<li>{{ .Props.label }}</li>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<If cond=".Show"><For each=".Items" as="item"><Item label="{{ .item }}" /></For></If>`)
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := range 16 {
+		wg.Go(func() {
+			item := strconv.Itoa(i)
+			var buf bytes.Buffer
+			data := map[string]any{"Show": i%2 == 0, "Items": []string{item, item}}
+			if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+				errs <- err
+				return
+			}
+			want := ""
+			if i%2 == 0 {
+				want = "<li>" + item + "</li><li>" + item + "</li>"
+			}
+			if buf.String() != want {
+				errs <- fmt.Errorf("render %d = %q, want %q", i, buf.String(), want)
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
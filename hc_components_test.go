@@ -0,0 +1,56 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestComponentNameFromFile(t *testing.T) {
+	t.Parallel()
+
+	for file, want := range map[string]string{
+		"components/with-children.html": "WithChildren",
+		"with_children.gohtml":          "WithChildren",
+		"user-card-list.tmpl":           "UserCardList",
+		"button.html":                   "Button",
+		"écran-large.html":              "ÉcranLarge",
+	} {
+		if got := componentNameFromFile(file); got != want {
+			t.Errorf("componentNameFromFile(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestComponents_NamesResolveToTheirFiles(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/with-children.html", `<div>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/nav_item.html", `<li>{{ .Props.label }}</li>`)
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	infos, err := engine.Components()
+	if err != nil {
+		t.Fatalf("Components: %v", err)
+	}
+	for _, info := range infos {
+		src, err := engine.lookupComponentSource(info.Name)
+		if err != nil {
+			t.Fatalf("lookup %s: %v", info.Name, err)
+		}
+		if src.source != info.Source {
+			t.Errorf("%s resolves to %s, want %s", info.Name, src.source, info.Source)
+		}
+	}
+
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<WithChildren><NavItem label="home" /></WithChildren>`)
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<div><li>home</li></div>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
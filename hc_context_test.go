@@ -226,6 +226,113 @@ func TestParseFileContext_FinalTemplatePass(t *testing.T) {
 	}
 }
 
+func TestParseFileContext_FinalTemplatePassIgnoresActionsInData(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/comment.html", `<p title="{{ .Props.author }}">{{ .Props.body }}{{ .Children }}</p>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Comment body="{{ .First }}" author="{"><em>{{ shout "by" }}</em></Comment><Comment body="{{ .Second }}" author="x" />`)
+
+	called := false
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithFinalTemplatePass(),
+		WithFuncMap(template.FuncMap{"shout": strings.ToUpper}),
+	)
+	data := map[string]any{
+		"Secret": "s3cret",
+		"Run":    func() string { called = true; return "ran" },
+		"First":  "{{ .Secret }} {{ call .Run }}",
+		"Second": "{ .Secret }}",
+	}
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<p title="{">{{ .Secret }} {{ call .Run }}<em>BY</em></p><p title="x">{ .Secret }}</p>`
+	if got := buf.String(); got != want || called {
+		t.Fatalf("rendered output mismatch (called=%v)\nwant: %q\ngot:  %q", called, want, got)
+	}
+}
+
+func TestParseFileContext_DataInNestedAttrsDoesNotRun(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/inner.html", `<p>{{ .Props.text }}</p>`)
+	writeTestFile(t, tmp, "components/wrap.html", `<Inner text="{{ .Props.note }}" />`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Wrap note="{{ .Note }}" />`)
+
+	data := map[string]any{"Note": "{{ .Secret }}", "Secret": "s3cret"}
+	for _, opts := range [][]Option{nil, {WithFinalTemplatePass()}} {
+		engine := NewHC(filepath.Join(tmp, "components"), opts...)
+		var buf bytes.Buffer
+		if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+		if got, want := buf.String(), `<p>{{ .Secret }}</p>`; got != want {
+			t.Fatalf("rendered output mismatch (%d options)\nwant: %q\ngot:  %q", len(opts), want, got)
+		}
+	}
+}
+
+func TestNeutralizeDelims_SwapsOnlyDelimiters(t *testing.T) {
+	t.Parallel()
+
+	engine := NewHC("components")
+	state := &renderState{delimMarker: "#"}
+	for input, want := range map[string]string{
+		`<script>var o = {a: {b: 1}};</script>`: `<script>var o = {a: {b: 1}};</script>`,
+		`x {{ .A }} {{{ y`:                      `x #a{ .A }} #a#a{ y`,
+		`{ .B }} z {`:                           `#b .B }} z #a`,
+	} {
+		if got := string(engine.neutralizeDelims(state, []byte(input))); got != want {
+			t.Errorf("neutralizeDelims(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseFileContext_FinalTemplatePassKeepsNestedAttrValues(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/chart.html", "{{/* @props options:json */}}<div data-a=\"{{ index .Props.options \"a\" }}\">{{ .Children }}</div>")
+	writeTestFile(t, tmp, "components/wrap.html", `<section><Chart options='{"a":1}'>{{ .Children }}</Chart>{{ .Props.note }}</section>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Wrap note="{{ .Note }}"><b>{{ shout "x" }}</b></Wrap>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithFinalTemplatePass(),
+		WithFuncMap(template.FuncMap{"shout": strings.ToUpper}),
+	)
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, map[string]any{"Note": "{{ .Note }}"}); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<section><div data-a="1"><b>X</b></div>{{ .Note }}</section>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestRenderComponent_ForwardedChildrenKeepRawMarkup(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/raw.html", `<pre>{{ .ChildrenRaw }}</pre>`)
+	writeTestFile(t, tmp, "components/wrap.html", `<Raw>{{ .Children }}</Raw>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Wrap><i>hi</i></Wrap>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<pre>&lt;i&gt;hi&lt;/i&gt;</pre>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
 func TestParseFileContext_PostProcessorReceivesContextAndFuncs(t *testing.T) {
 	t.Parallel()
 
@@ -438,6 +545,80 @@ func TestComponentInstrumentationCapturesErrors(t *testing.T) {
 	}
 }
 
+func TestComponentInstrumentationBuildsSpanTree(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	cardPath := writeTestFile(t, tmp, "components/card.html", `<section>{{ .Children }}</section>`)
+	writeTestFile(t, tmp, "components/badge.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card><Badge text="new" tone="info" /></Card>`)
+
+	ends := map[string]ComponentInstrumentationEvent{}
+	record := func(ctx context.Context, evt ComponentInstrumentationEvent) {
+		if evt.Stage == ComponentStageEnd || evt.Stage == PageStageEnd {
+			ends[evt.Component] = evt
+		}
+	}
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithComponentInstrumentation(record),
+		WithPageInstrumentation(record),
+	)
+
+	for range 2 {
+		if err := engine.ParseFileContext(context.Background(), nil, pagePath, nil); err != nil {
+			t.Fatalf("ParseFileContext: %v", err)
+		}
+	}
+
+	page, card, badge := ends[""], ends["Card"], ends["Badge"]
+	if page.SpanID == 0 || page.ParentID != 0 || page.Depth != 0 || page.Source != pagePath {
+		t.Fatalf("unexpected page event: %+v", page)
+	}
+	if page.Bytes != len("<section><b>new</b></section>") {
+		t.Fatalf("page bytes = %d", page.Bytes)
+	}
+	if card.ParentID != page.SpanID || card.Depth != 1 || card.Source != cardPath || !card.Cached {
+		t.Fatalf("unexpected Card event: %+v", card)
+	}
+	if badge.ParentID != card.SpanID || badge.Depth != 2 || badge.PropsCount != 2 || badge.Bytes != len("<b>new</b>") {
+		t.Fatalf("unexpected Badge event: %+v", badge)
+	}
+}
+
+func TestComponentInstrumentationNestsTemplateEmittedComponents(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/wrap.html", `<section><Inner /></section>`)
+	writeTestFile(t, tmp, "components/inner.html", `<p>inner</p>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Wrap />`)
+
+	var events []ComponentInstrumentationEvent
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithComponentInstrumentation(func(ctx context.Context, evt ComponentInstrumentationEvent) {
+			events = append(events, evt)
+		}),
+	)
+	if err := engine.ParseFileContext(context.Background(), nil, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	var order []string
+	for _, evt := range events {
+		order = append(order, string(evt.Stage)+" "+evt.Component)
+	}
+	if got, want := strings.Join(order, ", "), "begin Wrap, begin Inner, end Inner, end Wrap"; got != want {
+		t.Fatalf("event order = %s, want %s", got, want)
+	}
+	wrapEnd, innerEnd := events[3], events[2]
+	if innerEnd.ParentID != wrapEnd.SpanID {
+		t.Fatalf("Inner parent = %d, want %d", innerEnd.ParentID, wrapEnd.SpanID)
+	}
+	if wrapEnd.Bytes != len("<section><p>inner</p></section>") || wrapEnd.Duration < innerEnd.Duration {
+		t.Fatalf("Wrap does not cover its subtree: %+v", wrapEnd)
+	}
+}
+
 func TestComponentAugmenterInjectsDefaults(t *testing.T) {
 	t.Parallel()
 
@@ -635,7 +816,7 @@ func TestParseFileContext_ComponentParseErrorIncludesSource(t *testing.T) {
 	}
 
 	msg := err.Error()
-	if !strings.Contains(msg, filepath.ToSlash("components/Broken.html")) {
+	if !strings.Contains(strings.ToLower(msg), filepath.ToSlash("components/broken.html")) {
 		t.Fatalf("error missing component path; err=%v", err)
 	}
 	if !strings.Contains(msg, ":1") {
@@ -0,0 +1,51 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithDelims_LeavesClientTemplatesAlone(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/counter.html", `[[/* @props label! */]]<button @click="count++">[[ .Props.label ]]: {{ count }}</button>`)
+	writeTestFile(t, tmp, "components/item.html", `<li>[[ .Props.name ]]</li>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<h1>[[ .Title ]] {{ title }}</h1><Counter label="[[ .Label ]]" /><ul><For each="[[ .Items ]]" as="item"><Item name="[[ .item ]]" /></For></ul>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithDelims("[[", "]]"), WithFinalTemplatePass())
+	data := map[string]any{"Title": "Hi", "Label": "Clicks", "Items": []string{"a", "b"}}
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<h1>Hi {{ title }}</h1><button @click="count++">Clicks: {{ count }}</button><ul><li>a</li><li>b</li></ul>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+
+	if err := engine.ParseFileContext(context.Background(), nil, writeTestFile(t, tmp, "pages/missing.gohtml", `<Counter />`), nil); err == nil {
+		t.Fatal("expected @props declared with custom delimiters to be enforced")
+	}
+}
+
+func TestWithComponentDelims(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/vue-widget.html", `<div id="app"><% .Props.title %> {{ message }}</div>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<VueWidget title="{{ .Title }}" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithComponentDelims("VueWidget", "<%", "%>"))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, map[string]any{"Title": "Inbox"}); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<div id="app">Inbox {{ message }}</div>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
@@ -0,0 +1,62 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDeprecations_ReportedAndRenamed(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/button.html", `{{/* @props label! variant */}}{{/* @renamed kind variant */}}<button class="btn-{{ .Props.variant }}">{{ .Props.label }}</button>`)
+	writeTestFile(t, tmp, "components/old-card.html", `{{/* @deprecated use Card */}}<section>{{ .Children }}</section>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", "<OldCard>\n  <Button label=\"Go\" kind=\"primary\" size=\"lg\" />\n</OldCard>")
+
+	var deps []Deprecation
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithDeprecatedAttr("Button", "size", "sizes come from the container"),
+		WithAttrRules("Button", RequireAttrs("label"), AllowAttrs("variant", "size")),
+		WithDeprecationHandler(func(ctx context.Context, dep Deprecation) {
+			deps = append(deps, dep)
+		}),
+	)
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), "<section>\n  <button class=\"btn-primary\">Go</button>\n</section>"; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+
+	var got []string
+	for _, dep := range deps {
+		got = append(got, dep.String())
+	}
+	want := []string{
+		pagePath + `:1:1: component OldCard is deprecated: use Card`,
+		pagePath + `:2:3: component Button attr "kind" is deprecated, renamed to "variant"`,
+		pagePath + `:2:3: component Button attr "size" is deprecated: sizes come from the container`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected deprecations:\n%q", got)
+	}
+
+	diags, err := engine.Lint(context.Background(), []string{pagePath})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	var warnings []string
+	for _, d := range diags {
+		if d.Severity == SeverityWarning {
+			warnings = append(warnings, d.Origin.String()+": "+d.Message)
+		}
+	}
+	if !slices.Equal(warnings, want) {
+		t.Fatalf("unexpected lint warnings:\n%q", warnings)
+	}
+}
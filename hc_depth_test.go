@@ -0,0 +1,49 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender_ReportsComponentCycle(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/panel.html", `<section><Toolbar /></section>`)
+	writeTestFile(t, tmp, "components/toolbar.html", `<nav><Panel /></nav>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Panel />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	if err == nil || !strings.Contains(err.Error(), "component cycle: Panel → Toolbar → Panel") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestRender_AllowsRecursionUpToMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/node.html", `<li>{{ .Props.path }}{{ if lt (len .Props.path) 3 }}<ul><Node path="{{ .Props.path }}/x" /></ul>{{ end }}</li>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<ul><Node path="a" /></ul>`)
+
+	var buf bytes.Buffer
+	engine := NewHC(filepath.Join(tmp, "components"))
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := buf.String(); got != "<ul><li>a<ul><li>a/x</li></ul></li></ul>" {
+		t.Fatalf("unexpected output: %s", got)
+	}
+
+	writeTestFile(t, tmp, "components/deep.html", `<Node path="{{ .Props.path }}" />`)
+	deepPath := writeTestFile(t, tmp, "pages/deep.gohtml", `<Deep path="b" />`)
+	shallow := NewHC(filepath.Join(tmp, "components"), WithMaxDepth(2))
+	err := shallow.ParseFileContext(context.Background(), nil, deepPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "component nesting exceeded max depth 2: Deep → Node → Node") {
+		t.Fatalf("expected depth error, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package hc
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDiagnostics_ReportsNonFatalProblems(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<div>{{ if .Props.icon }}<i>{{ .Props.icon }}</i>{{ end }}<h2>{{ .Props.title }}</h2>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/panel.html", `<section>{{ with .Children }}{{ . }}{{ else }}empty{{ end }}</section>`)
+	writeTestFile(t, tmp, "components/badge.html", `<span>{{ .Props.tone }}</span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", strings.Join([]string{
+		`<Card title="ok">body</Card>`,
+		`<Card />`,
+		`<Panel />`,
+		`<Badge tone="info" size="lg" />`,
+		`<Badge color="red" />`,
+	}, "\n"))
+
+	var mu sync.Mutex
+	var handled []string
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithAttrRules("Badge", AllowAttrs("tone", "color")),
+		WithRenamedAttr("Badge", "color", "tone"),
+		WithLenientAttrs(),
+		WithDiagnostics(func(_ context.Context, d Diagnostic) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, d.String())
+		}),
+	)
+
+	var diags Diagnostics
+	var out strings.Builder
+	if err := engine.ParseFileContext(ContextWithDiagnostics(context.Background(), &diags), &out, pagePath, nil); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(out.String(), `<span>info</span>`) || !strings.Contains(out.String(), `<span>red</span>`) {
+		t.Fatalf("expected lenient render to succeed, got %s", out.String())
+	}
+
+	want := []string{
+		pagePath + ":2:1: warning: component Card uses .Props.title, which was not passed",
+		pagePath + ":2:1: warning: component Card renders .Children but was given none",
+		pagePath + `:4:1: warning: component Badge received unsupported attr "size"`,
+		pagePath + `:5:1: warning: component Badge attr "color" is deprecated, renamed to "tone"`,
+	}
+	var got []string
+	for _, d := range diags.List() {
+		if d.Severity != SeverityWarning {
+			t.Fatalf("expected warnings only, got %v", d)
+		}
+		got = append(got, d.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !slices.Equal(handled, want) {
+		t.Fatalf("handler saw different diagnostics:\n%s", strings.Join(handled, "\n"))
+	}
+}
+
+func TestDiagnostics_StrictAttrsStillFail(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/badge.html", `<span>{{ .Props.tone }}</span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Badge tone="info" size="lg" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithAttrRules("Badge", AllowAttrs("tone")))
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	if err == nil || !strings.Contains(err.Error(), `unsupported attr "size"`) {
+		t.Fatalf("expected unsupported attr error, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package hc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteError_DevPageShowsChainSourceAndProps(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<section>{{ .Children }}</section>`)
+	writeTestFile(t, tmp, "components/price.html", "<p>\n  {{ index .Props.amount 5 }}\n</p>")
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", "<main>\n  <Card>\n    <Price amount=\"12\" currency=\"<eur>\" />\n  </Card>\n</main>")
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithDevErrors(true))
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	if err == nil {
+		t.Fatal("expected render error")
+	}
+
+	rec := httptest.NewRecorder()
+	engine.WriteError(rec, err)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Render error in &lt;Price&gt;",
+		"<li>&lt;Card&gt; " + pagePath + ":2:3</li>",
+		"<li>&lt;Price&gt; " + pagePath + ":3:5</li>",
+		`<span class="hl"><i>3</i>    &lt;Price amount=&#34;12&#34;`,
+		`<span class="hl"><i>2</i>  {{ index .Props.amount 5 }}</span>`,
+		"<tr><td>currency</td><td>string</td><td>&lt;eur&gt;</td></tr>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("error page missing %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestWriteError_ProductionHidesDetails(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<section>{{ .Children }</section>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+
+	rec := httptest.NewRecorder()
+	engine.WriteError(rec, err)
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "card.html") {
+		t.Fatalf("expected bare 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	NewHC(filepath.Join(tmp, "components"), WithDevErrors(true)).WriteError(rec, err)
+	if !strings.Contains(rec.Body.String(), `<span class="hl"><i>1</i>&lt;section&gt;`) {
+		t.Fatalf("expected parse error excerpt:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,208 @@
+package hc
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLint_ReportsProblemsWithPositions(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/button.html", "{{/* @props label! variant */}}\n<button>{{ .Props.label }}</button>")
+	writeTestFile(t, tmp, "components/card.html", "<div>\n  {{ if .Props.title }}<h2>{{ .Props.title }}</h2>\n</div>")
+	writeTestFile(t, tmp, "components/orphan.html", `<p>unused</p>`)
+	writeTestFile(t, tmp, "components/tree.html", `<ul><Tree /></ul>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", strings.Join([]string{
+		`<Button label="ok" />`,
+		`<Button size="lg" />`,
+		`<Missing />`,
+		`<If cond=".Show"><Card title="x"></Card></If>`,
+		`<Tree />`,
+		`<Card>`,
+	}, "\n"))
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	diags, err := engine.Lint(context.Background(), []string{pagePath})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+
+	var got []string
+	for _, diag := range diags {
+		got = append(got, diag.String())
+	}
+	components := filepath.Join(tmp, "components")
+	want := []string{
+		filepath.Join(components, "card.html") + ":3: error: parse component Card: unexpected EOF",
+		filepath.Join(components, "orphan.html") + ":1:1: warning: component Orphan is never used",
+		filepath.Join(components, "tree.html") + ":1:5: warning: component cycle: Tree → Tree",
+		pagePath + `:2:1: error: component Button missing required attr "label"`,
+		pagePath + `:2:1: error: component Button received unsupported attr "size"`,
+		pagePath + ":3:1: error: unknown component Missing",
+		pagePath + ":6:1: error: unclosed component tag: Card",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLint_ChecksFunctionsAgainstFuncMap(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/label.html", `<span>{{ upper .Props.text }}</span>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	diags, err := engine.Lint(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, `function "upper" not defined`) {
+		t.Fatalf("expected undefined function diagnostic, got %v", diags)
+	}
+
+	diags, err = engine.Lint(context.Background(), nil, LintSkipFuncCheck())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("expected no diagnostics when skipping function checks, got %v", diags)
+	}
+}
+
+func TestLint_ChecksLiteralAttrValues(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/badge.html", `<span>{{ .Props.tone }}</span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Badge tone="loud" /><Badge tone="{{ .Tone }}" /><Badge tone="info" count="x" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithAttrRules("Badge", AttrEnum("tone", "info", "warn"), AttrType("count", Int)))
+	diags, err := engine.Lint(context.Background(), []string{pagePath})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	var messages []string
+	for _, d := range diags {
+		messages = append(messages, d.Message)
+	}
+	want := []string{
+		`component Badge attr "tone": must be one of "info", "warn", got "loud"`,
+		`component Badge attr "count": must be an int, got "x"`,
+	}
+	if !slices.Equal(messages, want) {
+		t.Fatalf("unexpected diagnostics:\n%s", strings.Join(messages, "\n"))
+	}
+}
+
+func TestPropsDeclaration_EnforcedWhileRendering(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/badge.html", "{{/*\n  @props tone! *\n*/}}<span>{{ .Props.tone }}</span>")
+	okPage := writeTestFile(t, tmp, "pages/ok.gohtml", `<Badge tone="info" data-id="1" />`)
+	badPage := writeTestFile(t, tmp, "pages/bad.gohtml", `<Badge data-id="1" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	if err := engine.ParseFileContext(context.Background(), nil, okPage, nil); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	err := engine.ParseFileContext(context.Background(), nil, badPage, nil)
+	if err == nil || !strings.Contains(err.Error(), `component Badge missing required attr "tone"`) {
+		t.Fatalf("expected declared props to be enforced, got %v", err)
+	}
+}
+
+func TestLint_ParsesAttributeExpressions(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", strings.Join([]string{
+		`<Label text="{{ .X " />`,
+		`<Label text="{{ nosuchfunc .X }}" />`,
+		`<Label text='{{ .X }} and {{ printf "%s" .Y }}' />`,
+	}, "\n"))
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	diags, err := engine.Lint(context.Background(), []string{pagePath})
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	want := []string{
+		pagePath + `:1:1: error: component Label attr "text": unclosed action`,
+		pagePath + `:2:1: error: component Label attr "text": function "nosuchfunc" not defined`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	diags, err = engine.Lint(context.Background(), []string{pagePath}, LintSkipFuncCheck())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, "unclosed action") {
+		t.Fatalf("expected only the parse error when skipping function checks, got %v", diags)
+	}
+}
+
+func TestLint_SkipsActionsInsideComponentFileTags(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/btn.html", "{{/* @props label! */}}<button>{{ .Props.label }}</button>")
+	writeTestFile(t, tmp, "components/toolbar.html", `<div><Btn label="go" {{ if .Props.big }}data-big{{ end }} /></div>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	diags, err := engine.Lint(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestLint_ReportsPageParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", strings.Join([]string{
+		`<Label text="{{ .X " />`,
+		`<p>{{ if .Show }}shown</p>`,
+	}, "\n"))
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	diags, err := engine.Lint(context.Background(), []string{pagePath}, LintSkipFuncCheck())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	want := []string{
+		pagePath + `:1:1: error: component Label attr "text": unclosed action`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	writeTestFile(t, tmp, "pages/page.gohtml", "<Label text=\"ok\" />\n<p>{{ if .Show }}shown</p>")
+	diags, err = engine.Lint(context.Background(), []string{pagePath}, LintSkipFuncCheck())
+	if err != nil {
+		t.Fatalf("lint: %v", err)
+	}
+	if len(diags) != 1 || diags[0].String() != pagePath+":2: error: parse page: unexpected EOF" {
+		t.Fatalf("expected a page parse error, got %v", diags)
+	}
+}
@@ -0,0 +1,97 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFile_HTML5PassesThroughUnchanged(t *testing.T) {
+	t.Parallel()
+
+	page := `<!DOCTYPE html>
+<html lang=en>
+<head>
+<meta charset=utf-8>
+<style>a > b { color: red }</style>
+<script>if (1 < 2 && x) { document.write("<Badge/>") }</script>
+</head>
+<body>
+<!-- <Badge label="commented out" /> -->
+<p>Tom&nbsp;&amp;&nbsp;Jerry<br>
+<input type=checkbox checked>
+<svg viewBox="0 0 10 10"><linearGradient id=g><stop offset=0 /></linearGradient><foreignObject></foreignObject></svg>
+<Badge label=new disabled />
+<ul><li>one<li>two</ul>
+</body>
+</html>
+`
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/badge.html", `<span{{ forwardAttrs .Attrs }}></span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", page)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := bytes.Replace([]byte(page), []byte(`<Badge label=new disabled />`), []byte(`<span label="new" disabled></span>`), 1)
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("rendered output mismatch\nwant: %s\ngot:  %s", want, got)
+	}
+}
+
+func TestParseFile_ComponentWithUnclosedHTMLChildren(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<div class="card">{{ .Children }}</div>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card><p>first<p>second<img src=a.png><Card>inner</Card></Card>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := `<div class="card"><p>first<p>second<img src=a.png><div class="card">inner</div></div>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestParseFile_NestedComponentNamedLikeRawTextElement(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/title.html", `<h1>{{ .Children }}</h1>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Title><Title>a</Title></Title><title>x<Title></title>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<h1><h1>a</h1></h1><title>x<Title></title>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestParseFile_UnclosedComponent(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<div>{{ .Children }}</div>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card><p>never closed`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	if err == nil || err.Error() != pagePath+":1:1: unclosed component tag: Card" {
+		t.Fatalf("expected unclosed component error, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeAttrs_CombinesClassAndStyle(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<section{{ mergeAttrs .Attrs (dict "class" "card" "style" "padding: 1rem;" "role" "region" "data-kind" "plain") "title" }}>{{ .Props.title }}</section>`)
+	writeTestFile(t, tmp, "components/tab.html", `<a class="{{ classNames "tab" (printf "tab-%s" .Props.size) (dict "active" .Props.active "disabled" false) "tab" }}">x</a>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card title="Hi" class="shadow card" style="color: red" data-kind="fancy" id="c" /><Card /><Tab size="lg" active />`)
+
+	var buf bytes.Buffer
+	engine := NewHC(filepath.Join(tmp, "components"))
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<section class="card shadow" data-kind="fancy" role="region" style="padding: 1rem; color: red" id="c">Hi</section>` +
+		`<section class="card" data-kind="plain" role="region" style="padding: 1rem;"></section>` +
+		`<a class="tab tab-lg active">x</a>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
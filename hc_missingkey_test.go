@@ -0,0 +1,64 @@
+package hc
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStrictMissingKeys_ReportsTypos(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", "{{/* @props title! icon */}}\n<div>{{ if .Props.icon }}<i>{{ .Props.icon }}</i>{{ end }}<h2>{{ .Props.titel }}</h2></div>")
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card title="Hello" />`)
+
+	lenient := NewHC(filepath.Join(tmp, "components"))
+	var out strings.Builder
+	if err := lenient.ParseFileContext(context.Background(), &out, pagePath, nil); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := out.String(); got != "\n<div><h2></h2></div>" {
+		t.Fatalf("expected the typo to render empty by default, got %q", got)
+	}
+
+	for _, engine := range []*HC{
+		NewHC(filepath.Join(tmp, "components"), WithStrictMissingKeys()),
+		NewHC(filepath.Join(tmp, "components"), WithStrictMissingKeys("card")),
+	} {
+		err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+		if err == nil {
+			t.Fatal("expected strict mode to fail on the typo")
+		}
+		for _, want := range []string{"render component Card", `<.Props.titel>`, `no entry for key "titel"`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("expected error to mention %q, got %v", want, err)
+			}
+		}
+	}
+}
+
+func TestStrictMissingKeys_DeclaredPropsStayOptional(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", "{{/* @props title! icon */}}\n<div>{{ if .Props.icon }}<i>{{ .Props.icon }}</i>{{ end }}<h2>{{ .Props.title }}</h2></div>")
+	writeTestFile(t, tmp, "components/other.html", `<p>{{ .Props.missing }}</p>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Card title="{{ .Title }}" /><Other />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithStrictMissingKeys("Card"))
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, map[string]any{"Title": "Hello"}); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := out.String(); got != "\n<div><h2>Hello</h2></div><p></p>" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	strict := NewHC(filepath.Join(tmp, "components"), WithStrictMissingKeys())
+	err := strict.ParseFileContext(context.Background(), nil, pagePath, map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), `no entry for key "Title"`) {
+		t.Fatalf("expected attribute expressions to be strict too, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package hc
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComponentError_ReportsPageLineAndStack(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<div>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/button.html", `<button>{{ .Props.label }}</button>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", "<Button label=\"ok\" />\n<Card>\n  <Button label=\"ok\" />\n  <Button />\n</Card>\n")
+
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithAttrRules("Button", RequireAttrs("label")),
+	)
+
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	var compErr *ComponentError
+	if !errors.As(err, &compErr) {
+		t.Fatalf("expected *ComponentError, got %T: %v", err, err)
+	}
+
+	want := Origin{File: pagePath, Line: 4, Column: 3}
+	if compErr.Component != "Button" || compErr.Origin != want {
+		t.Fatalf("unexpected failing invocation: %s at %s", compErr.Component, compErr.Origin)
+	}
+	if len(compErr.Stack) != 2 || compErr.Stack[0].Component != "Card" || compErr.Stack[0].Origin.Line != 2 {
+		t.Fatalf("unexpected stack: %v", compErr.Stack)
+	}
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, pagePath+":4:3: component Button missing required attr") {
+		t.Fatalf("error missing page position: %v", err)
+	}
+	if !strings.Contains(msg, "[stack: Card ("+pagePath+":2:1) > Button ("+pagePath+":4:3)]") {
+		t.Fatalf("error missing invocation stack: %v", err)
+	}
+}
+
+func TestComponentError_TemplateOutputNamesComponentFile(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	layoutPath := writeTestFile(t, tmp, "components/layout.html", `<main><Missing /></main>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", "\n\n  <Layout />")
+
+	var events []ComponentInstrumentationEvent
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithComponentInstrumentation(func(ctx context.Context, evt ComponentInstrumentationEvent) {
+			events = append(events, evt)
+		}),
+	)
+
+	err := engine.ParseFileContext(context.Background(), nil, pagePath, nil)
+	var compErr *ComponentError
+	if !errors.As(err, &compErr) {
+		t.Fatalf("expected *ComponentError, got %T: %v", err, err)
+	}
+	if compErr.Component != "Missing" || compErr.Origin != (Origin{File: layoutPath}) {
+		t.Fatalf("unexpected failing invocation: %s at %s", compErr.Component, compErr.Origin)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 instrumentation events, got %d", len(events))
+	}
+	if got := events[0].Origin; got != (Origin{File: pagePath, Line: 3, Column: 3}) {
+		t.Fatalf("Layout event origin = %s", got)
+	}
+	if stack := events[2].Stack; len(stack) != 2 || stack[0].Component != "Layout" || stack[1].Component != "Missing" {
+		t.Fatalf("unexpected nested event stack: %v", stack)
+	}
+}
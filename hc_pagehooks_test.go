@@ -0,0 +1,129 @@
+package hc
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageHooks_ReportRender(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/item.html", `<li>{{ .Props.label }}</li>`)
+	writeTestFile(t, tmp, "components/list.html", `<ul>{{ .Children }}</ul>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<List><Item label="{{ .A }}" /><Item label="b" /></List>`)
+
+	var before, after []PageRender
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithStreamingWrites(),
+		WithBeforeRender(func(_ context.Context, page *PageRender) error {
+			before = append(before, *page)
+			page.Data = map[string]any{"A": "replaced"}
+			return nil
+		}),
+		WithAfterRender(func(_ context.Context, page *PageRender) {
+			after = append(after, *page)
+		}),
+	)
+
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, map[string]any{"A": "a"}); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got, want := out.String(), "<ul><li>replaced</li><li>b</li></ul>"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(before) != 1 || before[0].Page != pagePath || before[0].Data.(map[string]any)["A"] != "a" {
+		t.Fatalf("unexpected before hook calls: %+v", before)
+	}
+	if len(after) != 1 {
+		t.Fatalf("expected one after hook call, got %d", len(after))
+	}
+	page := after[0]
+	if page.Components != 3 || page.Bytes != out.Len() || string(page.Output) != out.String() || page.Err != nil || page.Duration <= 0 {
+		t.Fatalf("unexpected after hook page: %+v", page)
+	}
+}
+
+func TestPageHooks_ReplaceOrVetoOutput(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/broken.html", `{{ .Props.x.Missing }}`)
+	brokenPage := writeTestFile(t, tmp, "pages/broken.gohtml", `<Broken x="1" />`)
+	okPage := writeTestFile(t, tmp, "pages/ok.gohtml", `<p>secret</p>`)
+
+	denied := errors.New("denied")
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithAfterRender(func(_ context.Context, page *PageRender) {
+			switch {
+			case page.Err != nil:
+				page.Output, page.Err = []byte("<p>fallback</p>"), nil
+			case strings.Contains(string(page.Output), "secret"):
+				page.Output, page.Err = nil, denied
+			}
+		}),
+	)
+
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, brokenPage, nil); err != nil {
+		t.Fatalf("expected fallback instead of error, got %v", err)
+	}
+	if out.String() != "<p>fallback</p>" {
+		t.Fatalf("expected fallback output, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := engine.ParseFileContext(context.Background(), &out, okPage, nil); !errors.Is(err, denied) {
+		t.Fatalf("expected veto error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing written after a veto, got %q", out.String())
+	}
+}
+
+func TestPageHooks_BeforeRenderAborts(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<p>hi</p>`)
+
+	stop := errors.New("stop")
+	engine := NewHC(filepath.Join(tmp, "components"), WithBeforeRender(func(context.Context, *PageRender) error {
+		return stop
+	}))
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, nil); !errors.Is(err, stop) || out.Len() != 0 {
+		t.Fatalf("expected abort, got %v with %q", err, out.String())
+	}
+}
+
+func TestPageHooks_AfterRenderSeesBeforeRenderError(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<p>hi</p>`)
+
+	stop := errors.New("stop")
+	var seen error
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithBeforeRender(func(context.Context, *PageRender) error { return stop }),
+		WithAfterRender(func(_ context.Context, page *PageRender) {
+			seen = page.Err
+			page.Output, page.Err = []byte("<p>denied</p>"), nil
+		}),
+	)
+	var out strings.Builder
+	if err := engine.ParseFileContext(context.Background(), &out, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if !errors.Is(seen, stop) {
+		t.Fatalf("AfterRender saw %v, want %v", seen, stop)
+	}
+	if got, want := out.String(), "<p>denied</p>"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
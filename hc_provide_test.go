@@ -0,0 +1,79 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestProvide_TemplateValuesReachDescendants(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/form.html", `{{ provide $ "form" .Props.id }}<form id="{{ .Props.id }}">{{ .Children }}</form>`)
+	writeTestFile(t, tmp, "components/row.html", `<div class="row">{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/input.html", `<input name="{{ .Props.name }}" form="{{ .Context.form }}" />`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<Form id="signup"><Row><Input name="email" /></Row></Form><Input name="outside" />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := `<form id="signup"><div class="row"><input name="email" form="signup" /></div></form><input name="outside" form="" />`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestProvide_AugmenterValuesAreScopedAndOverridable(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/sized.html", `<section>{{ .Children }}</section>`)
+	writeTestFile(t, tmp, "components/chip.html", `<span class="{{ .Context.size }}"></span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<Sized size="lg"><Chip /><Sized size="sm"><Chip /></Sized><Chip /></Sized>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"),
+		WithComponentAugmenter("Sized", func(ctx context.Context, name string, payload map[string]any) error {
+			props := payload["Props"].(map[string]any)
+			payload["Provide"].(map[string]any)["size"] = props["size"]
+			return nil
+		}),
+	)
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := `<section><span class="lg"></span><section><span class="sm"></span></section><span class="lg"></span></section>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestProvide_ApplicationFuncKeepsItsName(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/badge.html", `<span>{{ provide "x" }}</span>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<Badge />`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithFuncMap(map[string]any{
+		"provide": func(s string) string { return "app:" + s },
+	}))
+
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, nil); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	if got, want := buf.String(), `<span>app:x</span>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
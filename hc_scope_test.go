@@ -0,0 +1,110 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestScope_ChildrenSeeParentProps(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/user-card.html", `<article>{{ .Children }}</article>`)
+	writeTestFile(t, tmp, "components/section.html", `<section data-parent="{{ .Parent.name }}">{{ .Children }}</section>`)
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<UserCard name="{{ .User }}"><Label text="{{ .Parent.name }} @ {{ .Root.Site }}" />`+
+			`<Section name="bio"><Label text="{{ .Parent.name }}/{{ .Site }}" /></Section></UserCard>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	data := map[string]any{"User": "ada", "Site": "hc"}
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := `<article><b>ada @ hc</b><section data-parent="ada"><b>bio/hc</b></section></article>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestScope_StructRootDataStaysReachable(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/box.html", `<div>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<Box tone="warm"><Label text="{{ .Parent.tone }} {{ .Root.Title }}" /></Box>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	var buf bytes.Buffer
+	data := struct{ Title string }{Title: "home"}
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	if got, want := buf.String(), `<div><b>warm home</b></div>`; got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+type scopePage struct {
+	Title string
+	User  struct{ Name string }
+}
+
+func (p scopePage) Heading() string { return "# " + p.Title }
+
+func TestScope_ChildrenResolveStructRootFields(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/card.html", `<div>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<Card tone="warm"><Label text="{{ .Title }} {{ .Heading }} {{ .User.Name }} {{ .Parent.tone }}" /></Card>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"))
+	page := scopePage{Title: "T"}
+	page.User.Name = "ada"
+
+	for _, data := range []any{page, &page} {
+		var buf bytes.Buffer
+		if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+			t.Fatalf("ParseFileContext(%T): %v", data, err)
+		}
+		if got, want := buf.String(), `<div><b>T # T ada warm</b></div>`; got != want {
+			t.Fatalf("rendered output mismatch for %T\nwant: %q\ngot:  %q", data, want, got)
+		}
+	}
+}
+
+func TestScope_ChildrenSeeWholeRootThroughDot(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/box.html", `<div>{{ .Children }}</div>`)
+	writeTestFile(t, tmp, "components/label.html", `<b>{{ .Props.text }}</b>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml",
+		`<Box tone="warm"><Label text='{{ index . "og-image" }}' /><Label text='{{ .Parent.tone }} {{ index $ "og-image" }}' /></Box>`+
+			`<For each="{{ .Items }}" as="item"><Label text='{{ .item }}:{{ index . "og-image" }}' /></For>`)
+
+	engine := NewHC(filepath.Join(tmp, "components"), WithStrictMissingKeys())
+
+	var buf bytes.Buffer
+	data := map[string]any{"og-image": "x.png", "Items": []string{"a"}}
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+
+	want := `<div><b>x.png</b><b>warm x.png</b></div><b>a:x.png</b>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package hc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpreadAttr_MergesMapIntoProps(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/button.html", `{{/* @props label! variant * */}}<button{{ forwardAttrs .Attrs "label" }}>{{ .Props.label }}{{ .Children }}</button>`)
+	writeTestFile(t, tmp, "components/icon-button.html", `<Button hc-spread=".Parent" variant="icon"><i class="i-{{ .Props.icon }}"></i></Button>`)
+	pagePath := writeTestFile(t, tmp, "pages/page.gohtml", `<IconButton label="Save" variant="primary" icon="disk" /><Button hc-spread="{{ .Defaults }}" label="Go" />`)
+
+	data := map[string]any{
+		"Defaults": map[string]string{"label": "Default", "id": "a", "title": "first"},
+	}
+	var buf bytes.Buffer
+	engine := NewHC(filepath.Join(tmp, "components"))
+	if err := engine.ParseFileContext(context.Background(), &buf, pagePath, data); err != nil {
+		t.Fatalf("ParseFileContext: %v", err)
+	}
+	want := `<button icon="disk" variant="icon">Save<i class="i-disk"></i></button><button id="a" title="first">Go</button>`
+	if got := buf.String(); got != want {
+		t.Fatalf("rendered output mismatch\nwant: %q\ngot:  %q", want, got)
+	}
+}
+
+func TestSpreadAttr_ValidatesMergedProps(t *testing.T) {
+	t.Parallel()
+
+	tmp := t.TempDir()
+	writeTestFile(t, tmp, "components/button.html", `{{/* @props label! */}}<button>{{ .Props.label }}</button>`)
+	engine := NewHC(filepath.Join(tmp, "components"))
+
+	for page, want := range map[string]string{
+		`<Button hc-spread=".Props" />`:           `missing required attr "label"`,
+		`<Button hc-spread=".Props" label="x" />`: `unsupported attr "size"`,
+		`<Button hc-spread=".Label" />`:           "expected a map with string keys, got string",
+	} {
+		pagePath := writeTestFile(t, tmp, "pages/page.gohtml", page)
+		err := engine.ParseFileContext(context.Background(), nil, pagePath, map[string]any{"Props": map[string]any{"size": "lg"}, "Label": "x"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", page, want, err)
+		}
+	}
+}
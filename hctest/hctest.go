@@ -0,0 +1,233 @@
+// Package hctest renders hc pages and components in tests and compares the
+// output with golden files.
+//
+// Comparisons are HTML-aware: whitespace between and inside text runs is
+// collapsed (except inside <pre> and <textarea>), attributes are compared in
+// name order, and <br/> matches <br>. Set HCTEST_UPDATE to rewrite the
+// golden files from the current output:
+//
+//	HCTEST_UPDATE=1 go test ./...
+//
+// The -hctest.update flag does the same for a single package. It is
+// namespaced so it does not clash with a package's own -update flag.
+package hctest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/esrid/hc"
+	"github.com/esrid/hc/internal/markup"
+)
+
+var update = flag.Bool("hctest.update", false, "rewrite hctest golden files with the current output")
+
+// updating reports whether golden files should be rewritten.
+func updating() bool {
+	return *update || os.Getenv("HCTEST_UPDATE") != ""
+}
+
+// ComponentFolder is the folder NewEngine stores components under.
+const ComponentFolder = "components"
+
+// NewEngine returns an engine reading from an in-memory filesystem built from
+// files, which maps slash-separated paths such as "components/button.html" or
+// "pages/home.gohtml" to their contents.
+func NewEngine(files map[string]string, opts ...hc.Option) *hc.HC {
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return hc.NewHC(ComponentFolder, append([]hc.Option{hc.WithFileSystem(fsys)}, opts...)...)
+}
+
+// Page renders page with data and compares the result with golden.
+func Page(t testing.TB, engine *hc.HC, page string, data any, golden string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := engine.ParseFileContext(context.Background(), &buf, page, data); err != nil {
+		t.Fatalf("render %s: %v", page, err)
+	}
+	Golden(t, buf.Bytes(), golden)
+}
+
+// Component renders a single component and compares the result with golden.
+func Component(t testing.TB, engine *hc.HC, name string, call hc.ComponentCall, golden string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := engine.RenderComponent(context.Background(), &buf, name, call); err != nil {
+		t.Fatalf("render component %s: %v", name, err)
+	}
+	Golden(t, buf.Bytes(), golden)
+}
+
+// Golden compares got with the golden file at path, or rewrites the file
+// when HCTEST_UPDATE or -hctest.update is set. Golden files hold normalized
+// markup, one tag or text run per line.
+func Golden(t testing.TB, got []byte, path string) {
+	t.Helper()
+	normalized := Normalize(got)
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(normalized), 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (set HCTEST_UPDATE=1 to create it): %v", err)
+	}
+	if diff := Diff(Normalize(want), normalized); diff != "" {
+		t.Errorf("output does not match %s (-want +got):\n%s", path, diff)
+	}
+}
+
+// Equal reports whether two fragments of markup are the same after
+// normalization.
+func Equal(a, b []byte) bool {
+	return Normalize(a) == Normalize(b)
+}
+
+// voidElements never have content or an end tag.
+var voidElements = map[string]struct{}{
+	"area": {}, "base": {}, "br": {}, "col": {}, "embed": {}, "hr": {}, "img": {},
+	"input": {}, "link": {}, "meta": {}, "source": {}, "track": {}, "wbr": {},
+}
+
+// Normalize rewrites markup into a canonical form: one tag, comment or text
+// run per line, indented by nesting depth.
+func Normalize(input []byte) string {
+	var (
+		b        strings.Builder
+		depth    int
+		preserve int
+	)
+	line := func(s string) {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+
+	z := markup.NewTokenizer(input)
+	for {
+		tok, ok := z.Next()
+		if !ok {
+			break
+		}
+		raw := string(input[tok.Start:tok.End])
+		switch tok.Kind {
+		case markup.Text:
+			if preserve > 0 {
+				if raw != "" {
+					line(fmt.Sprintf("%q", html.UnescapeString(raw)))
+				}
+				continue
+			}
+			if text := strings.Join(strings.Fields(html.UnescapeString(raw)), " "); text != "" {
+				line(html.EscapeString(text))
+			}
+		case markup.StartTag, markup.SelfClosingTag:
+			name := strings.ToLower(tok.Name)
+			line(startTag(name, tok.Attrs))
+			if _, void := voidElements[name]; void {
+				continue
+			}
+			if tok.Kind == markup.SelfClosingTag {
+				line("</" + name + ">")
+				continue
+			}
+			depth++
+			if name == "pre" || name == "textarea" {
+				preserve++
+			}
+		case markup.EndTag:
+			name := strings.ToLower(tok.Name)
+			if _, void := voidElements[name]; void {
+				continue
+			}
+			if (name == "pre" || name == "textarea") && preserve > 0 {
+				preserve--
+			}
+			depth = max(depth-1, 0)
+			line("</" + name + ">")
+		default:
+			line(strings.TrimSpace(raw))
+		}
+	}
+	return b.String()
+}
+
+func startTag(name string, attrs []markup.Attr) string {
+	sorted := slices.Clone(attrs)
+	slices.SortFunc(sorted, func(a, b markup.Attr) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	var b strings.Builder
+	b.WriteString("<" + name)
+	for _, attr := range sorted {
+		b.WriteString(" " + strings.ToLower(attr.Name))
+		if attr.HasValue {
+			b.WriteString(`="` + html.EscapeString(attr.Value) + `"`)
+		}
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// Diff returns a line diff of want and got, or "" when they are equal.
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	c := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and c[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(c)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(c) - 1; j >= 0; j-- {
+			if a[i] == c[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(c) {
+		switch {
+		case i < len(a) && j < len(c) && a[i] == c[j]:
+			b.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(c) || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			b.WriteString("+ " + c[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package hctest
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/esrid/hc"
+)
+
+// Packages using hctest commonly define their own -update flag; registering
+// it here panics at init if hctest claims the same name.
+var _ = flag.Bool("update", false, "rewrite this package's golden files")
+
+func TestUpdateFlagIsNamespaced(t *testing.T) {
+	t.Parallel()
+
+	if flag.Lookup("hctest.update") == nil {
+		t.Fatal("expected hctest to register -hctest.update")
+	}
+}
+
+func newTestEngine() *hc.HC {
+	return NewEngine(map[string]string{
+		"components/card.html":   `<section class="card" {{ forwardAttrs .Attrs "title" }}><h2>{{ .Props.title }}</h2>{{ .Children }}</section>`,
+		"components/button.html": `<button type="button" {{ forwardAttrs .Attrs "label" }}>{{ .Props.label }}</button>`,
+		"pages/home.gohtml": `<main>
+  <Card title="{{ .Title }}" id="intro">
+    <p>Hello,   world</p>
+    <Button label="Go" data-kind="primary" />
+  </Card>
+</main>`,
+	})
+}
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+	Page(t, newTestEngine(), "pages/home.gohtml", map[string]any{"Title": "Welcome"}, "testdata/home.golden.html")
+}
+
+func TestComponent(t *testing.T) {
+	t.Parallel()
+	Component(t, newTestEngine(), "Card", hc.ComponentCall{
+		Props:    map[string]any{"title": "Solo", "data-x": 1},
+		Children: `<Button label="Inside" />`,
+	}, "testdata/card.golden.html")
+}
+
+func TestEqual_IgnoresWhitespaceAndAttributeOrder(t *testing.T) {
+	t.Parallel()
+
+	a := []byte(`<div class="a" id="b"><p>one   two</p><br/><input disabled></div>`)
+	b := []byte("<div id=\"b\" class=\"a\">\n  <p>\n    one two\n  </p>\n  <br>\n  <input disabled>\n</div>\n")
+	if !Equal(a, b) {
+		t.Fatalf("expected equal markup:\n%s\n%s", Normalize(a), Normalize(b))
+	}
+	if Equal([]byte(`<pre>a  b</pre>`), []byte(`<pre>a b</pre>`)) {
+		t.Fatal("expected whitespace inside <pre> to matter")
+	}
+	if Equal([]byte(`<a href="/x">x</a>`), []byte(`<a href="/y">x</a>`)) {
+		t.Fatal("expected attribute values to matter")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	got := Diff("<p>\n  a\n</p>\n", "<p>\n  b\n</p>\n")
+	want := "  <p>\n-   a\n+   b\n  </p>\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if Diff("same\n", "same\n") != "" {
+		t.Fatal("expected no diff for equal input")
+	}
+}
@@ -0,0 +1,329 @@
+// Package gallery serves a browsable catalogue of an engine's components.
+//
+// Every component in the engine's component folder is listed. Its page
+// renders each example live with the same engine and func map the
+// application uses, side by side, along with the component's attribute rules
+// and template source.
+//
+// Examples live next to the component in a sidecar file named after it, such
+// as button.examples.yaml (or .yml, or .json) for button.html:
+//
+//	# button.examples.yaml
+//	- name: Primary
+//	  props: {label: Save, variant: primary}
+//	- name: With icon
+//	  props: {label: Next}
+//	  children: <svg class="icon"><use href="#arrow" /></svg>
+//
+// Components without examples are rendered once with no props.
+package gallery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/esrid/hc"
+	"github.com/esrid/hc/internal/yaml"
+)
+
+type Options struct {
+	// Title heads the gallery pages. It defaults to "Components".
+	Title string
+	// Head is extra markup for the <head> of pages showing rendered
+	// components, typically the application's stylesheets.
+	Head template.HTML
+	// Data is the root data components see while rendering examples.
+	Data any
+}
+
+// Example is one set of props and children to render a component with.
+type Example struct {
+	Name     string         `json:"name"`
+	Props    map[string]any `json:"props"`
+	Children string         `json:"children"`
+}
+
+type handler struct {
+	engine *hc.HC
+	opts   Options
+}
+
+// Handler returns the gallery. Its pages link to each other with relative
+// URLs, so mount it on a subtree with http.StripPrefix:
+//
+//	mux.Handle("/_gallery/", http.StripPrefix("/_gallery", gallery.Handler(engine, gallery.Options{})))
+func Handler(engine *hc.HC, opts Options) http.Handler {
+	if opts.Title == "" {
+		opts.Title = "Components"
+	}
+	return &handler{engine: engine, opts: opts}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	components, err := h.engine.Components()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name, action, _ := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+	if name == "" {
+		h.serveIndex(w, r, components)
+		return
+	}
+
+	for _, info := range components {
+		if info.Name != name {
+			continue
+		}
+		switch action {
+		case "":
+			h.serveComponent(w, r, info)
+		case "preview":
+			h.servePreview(w, r, info)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+type indexEntry struct {
+	hc.ComponentInfo
+	Examples int
+	Err      error
+}
+
+func (h *handler) serveIndex(w http.ResponseWriter, r *http.Request, components []hc.ComponentInfo) {
+	entries := make([]indexEntry, 0, len(components))
+	for _, info := range components {
+		examples, err := h.examples(info)
+		entries = append(entries, indexEntry{ComponentInfo: info, Examples: len(examples), Err: err})
+	}
+	h.write(w, indexTemplate, map[string]any{
+		"Title":      h.opts.Title,
+		"Components": entries,
+	})
+}
+
+type renderedExample struct {
+	Example
+	Index  int
+	Props  string
+	Output template.HTML
+	Err    error
+}
+
+func (h *handler) serveComponent(w http.ResponseWriter, r *http.Request, info hc.ComponentInfo) {
+	examples, err := h.examples(info)
+	rendered := make([]renderedExample, 0, len(examples))
+	for i, example := range examples {
+		props, _ := json.MarshalIndent(example.Props, "", "  ")
+		var buf bytes.Buffer
+		renderErr := h.engine.RenderComponent(r.Context(), &buf, info.Name, hc.ComponentCall{
+			Props:    example.Props,
+			Children: example.Children,
+			Data:     h.opts.Data,
+		})
+		rendered = append(rendered, renderedExample{
+			Example: example,
+			Index:   i,
+			Props:   string(props),
+			// The engine escapes component output, so it is safe to embed.
+			Output: template.HTML(buf.String()),
+			Err:    renderErr,
+		})
+	}
+
+	h.write(w, componentTemplate, map[string]any{
+		"Title":     h.opts.Title,
+		"Head":      h.opts.Head,
+		"Component": info,
+		"Examples":  rendered,
+		"Err":       err,
+	})
+}
+
+// servePreview renders a single example on an otherwise empty page, for
+// opening on its own or embedding in an iframe.
+func (h *handler) servePreview(w http.ResponseWriter, r *http.Request, info hc.ComponentInfo) {
+	examples, err := h.examples(info)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	index, err := strconv.Atoi(r.URL.Query().Get("example"))
+	if err != nil || index < 0 || index >= len(examples) {
+		http.NotFound(w, r)
+		return
+	}
+
+	example := examples[index]
+	var buf bytes.Buffer
+	err = h.engine.RenderComponent(r.Context(), &buf, info.Name, hc.ComponentCall{
+		Props:    example.Props,
+		Children: example.Children,
+		Data:     h.opts.Data,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.write(w, previewTemplate, map[string]any{
+		"Title":  info.Name + " · " + example.Name,
+		"Head":   h.opts.Head,
+		"Output": template.HTML(buf.String()),
+	})
+}
+
+// examples loads the sidecar examples of a component, falling back to a
+// single example without props.
+func (h *handler) examples(info hc.ComponentInfo) ([]Example, error) {
+	base := strings.TrimSuffix(info.Source, path.Ext(info.Source))
+	for _, ext := range []string{".examples.yaml", ".examples.yml", ".examples.json"} {
+		content, err := h.engine.ReadFile(base + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		examples, err := decodeExamples(ext, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %w", base, ext, err)
+		}
+		return examples, nil
+	}
+	return []Example{{Name: "Default"}}, nil
+}
+
+func decodeExamples(ext string, content []byte) ([]Example, error) {
+	if ext != ".examples.json" {
+		// Round-trip through JSON so both formats share the struct tags.
+		value, err := yaml.Unmarshal(content)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var examples []Example
+	if err := json.Unmarshal(content, &examples); err != nil {
+		return nil, err
+	}
+	for i := range examples {
+		if examples[i].Name == "" {
+			examples[i].Name = fmt.Sprintf("Example %d", i+1)
+		}
+	}
+	return examples, nil
+}
+
+func (h *handler) write(w http.ResponseWriter, tpl *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+const styles = `
+body { font: 15px/1.5 system-ui, sans-serif; margin: 0; color: #1f2328; }
+header { padding: 1rem 2rem; border-bottom: 1px solid #d0d7de; }
+header a { color: inherit; }
+main { padding: 1rem 2rem; }
+table { border-collapse: collapse; }
+td, th { text-align: left; padding: .25rem .75rem .25rem 0; vertical-align: top; }
+code, pre { font: 13px/1.4 ui-monospace, monospace; }
+pre { background: #f6f8fa; padding: 1rem; overflow: auto; }
+.variants { display: grid; grid-template-columns: repeat(auto-fill, minmax(280px, 1fr)); gap: 1rem; }
+.variant { border: 1px solid #d0d7de; border-radius: 6px; }
+.variant h3 { margin: 0; padding: .5rem 1rem; font-size: 14px; border-bottom: 1px solid #d0d7de; display: flex; justify-content: space-between; }
+.variant .stage { padding: 1rem; }
+.variant details { padding: 0 1rem 1rem; }
+.error { color: #cf222e; white-space: pre-wrap; }
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Title }}</title><style>` + styles + `</style></head>
+<body>
+<header><h1>{{ .Title }}</h1></header>
+<main>
+<table>
+<tr><th>Component</th><th>Attributes</th><th>Examples</th><th>Source</th></tr>
+{{ range .Components -}}
+<tr>
+  <td><a href="{{ .Name }}">{{ .Name }}</a></td>
+  <td>{{ with .Rules }}{{ range $i, $a := .Allowed }}{{ if $i }}, {{ end }}<code>{{ $a }}</code>{{ end }}{{ if .AllowOthers }} …{{ end }}{{ else }}any{{ end }}</td>
+  <td>{{ if .Err }}<span class="error">{{ .Err }}</span>{{ else }}{{ .Examples }}{{ end }}</td>
+  <td><code>{{ .Source }}</code></td>
+</tr>
+{{ end -}}
+</table>
+</main>
+</body>
+</html>
+`))
+
+var componentTemplate = template.Must(template.New("component").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Component.Name }} · {{ .Title }}</title><style>` + styles + `</style>{{ .Head }}</head>
+<body>
+<header><a href="./">{{ .Title }}</a> / <strong>{{ .Component.Name }}</strong></header>
+<main>
+{{ with .Err }}<p class="error">{{ . }}</p>{{ end }}
+<section class="variants">
+{{ range .Examples -}}
+<article class="variant">
+  <h3><span>{{ .Name }}</span><a href="{{ $.Component.Name }}/preview?example={{ .Index }}">open</a></h3>
+  <div class="stage">{{ if .Err }}<p class="error">{{ .Err }}</p>{{ else }}{{ .Output }}{{ end }}</div>
+  <details><summary>Props</summary><pre>{{ .Props }}</pre>{{ with .Children }}<pre>{{ . }}</pre>{{ end }}</details>
+</article>
+{{ end -}}
+</section>
+<h2>Attributes</h2>
+{{ with .Component.Rules -}}
+<table>
+<tr><th>Name</th><th>Required</th><th>Type</th><th>Values</th><th>Default</th></tr>
+{{ $rules := . }}{{ range .Allowed -}}
+<tr><td><code>{{ . }}</code></td><td>{{ $name := . }}{{ range $rules.Required }}{{ if eq . $name }}yes{{ end }}{{ end }}</td>
+<td>{{ with index $rules.Types . }}{{ . }}{{ end }}</td>
+<td>{{ with index $rules.Enums . }}{{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}{{ end }}{{ with index $rules.Patterns . }}<code>{{ . }}</code>{{ end }}</td>
+<td>{{ with index $rules.Defaults . }}<code>{{ . }}</code>{{ end }}</td></tr>
+{{ end -}}
+</table>
+{{ range .Exclusive }}<p>At most one of {{ range $i, $a := . }}{{ if $i }}, {{ end }}<code>{{ $a }}</code>{{ end }}.</p>
+{{ end }}{{ range .Together }}<p>All or none of {{ range $i, $a := . }}{{ if $i }}, {{ end }}<code>{{ $a }}</code>{{ end }}.</p>
+{{ end }}{{ if .AllowOthers }}<p>Other attributes are accepted too.</p>{{ end }}
+{{- else -}}
+<p>No attribute rules; any attribute is accepted.</p>
+{{- end }}
+<h2>Source <small><code>{{ .Component.Source }}</code></small></h2>
+<pre>{{ .Component.Template }}</pre>
+</main>
+</body>
+</html>
+`))
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Title }}</title>{{ .Head }}</head>
+<body>
+{{ .Output }}
+</body>
+</html>
+`))
@@ -0,0 +1,117 @@
+package gallery
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/esrid/hc"
+)
+
+func newTestHandler() http.Handler {
+	fsys := fstest.MapFS{
+		"components/button.html": {Data: []byte("{{/* @props label! variant */}}<button class=\"btn-{{ .Props.variant }}\">{{ shout .Props.label }}</button>")},
+		"components/button.examples.yaml": {Data: []byte(`- name: Primary
+  props: {label: Save, variant: primary}
+- name: Secondary
+  props:
+    label: Cancel
+    variant: secondary
+`)},
+		"components/card.html":          {Data: []byte(`<section>{{ .Children }}</section>`)},
+		"components/card.examples.json": {Data: []byte(`[{"name": "Nested", "children": "<Button label=\"Inside\" />"}]`)},
+		"components/badge.html":         {Data: []byte(`<span>{{ .Props.text }}</span>`)},
+	}
+	engine := hc.NewHC("components",
+		hc.WithFileSystem(fsys),
+		hc.WithFuncMap(template.FuncMap{"shout": strings.ToUpper}),
+		hc.WithAttrRules("Badge",
+			hc.AttrEnum("tone", "info", "warn"), hc.AttrDefault("tone", "info"),
+			hc.AttrType("count", hc.Int), hc.AttrExclusive("text", "count"),
+		),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("/_gallery/", http.StripPrefix("/_gallery", Handler(engine, Options{Head: `<link rel="stylesheet" href="/app.css">`})))
+	return mux
+}
+
+func get(t *testing.T, handler http.Handler, target string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestIndexListsComponents(t *testing.T) {
+	t.Parallel()
+
+	code, body := get(t, newTestHandler(), "/_gallery/")
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %s", code, body)
+	}
+	for _, want := range []string{`href="Badge"`, `href="Button"`, `href="Card"`, "<code>label</code>, <code>variant</code>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index missing %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestComponentPageRendersVariants(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandler()
+	code, body := get(t, handler, "/_gallery/Button")
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %s", code, body)
+	}
+	for _, want := range []string{
+		`<button class="btn-primary">SAVE</button>`,
+		`<button class="btn-secondary">CANCEL</button>`,
+		`<link rel="stylesheet" href="/app.css">`,
+		`href="Button/preview?example=1"`,
+		"<tr><td><code>label</code></td><td>yes</td>",
+		"{{/* @props label! variant */}}",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("component page missing %s:\n%s", want, body)
+		}
+	}
+
+	_, body = get(t, handler, "/_gallery/Card")
+	if !strings.Contains(body, "<section><button class=\"btn-\">INSIDE</button></section>") {
+		t.Errorf("expected nested component to render:\n%s", body)
+	}
+
+	_, body = get(t, handler, "/_gallery/Badge")
+	if !strings.Contains(body, "<span></span>") {
+		t.Errorf("expected default example for component without examples:\n%s", body)
+	}
+	for _, want := range []string{
+		"<td>int</td>",
+		"<td><code>info</code>, <code>warn</code></td>\n<td><code>info</code></td>",
+		"<p>At most one of <code>text</code>, <code>count</code>.</p>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Badge attributes missing %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandler()
+	code, body := get(t, handler, "/_gallery/Button/preview?example=1")
+	if code != http.StatusOK || !strings.Contains(body, "<body>\n<button class=\"btn-secondary\">CANCEL</button>") {
+		t.Fatalf("unexpected preview %d:\n%s", code, body)
+	}
+	if code, _ := get(t, handler, "/_gallery/Button/preview?example=9"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown example, got %d", code)
+	}
+	if code, _ := get(t, handler, "/_gallery/Missing"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown component, got %d", code)
+	}
+}
@@ -0,0 +1,235 @@
+// Package metrics collects render statistics from an hc engine and exposes
+// them through expvar and the Prometheus text format.
+//
+// A Collector counts renders, errors and bytes per component and per page,
+// keeps latency histograms for both, and counts component template cache
+// hits and misses:
+//
+//	collector := metrics.New(metrics.Options{})
+//	engine := hc.NewHC("web/components", collector.Option())
+//	collector.Publish("hc")
+//	mux.Handle("/metrics", collector.Handler())
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/esrid/hc"
+)
+
+// DefaultBuckets are the latency histogram bounds in seconds, sized for
+// template renders rather than whole requests.
+var DefaultBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+type Options struct {
+	// Buckets are the upper bounds of the latency histograms in seconds, in
+	// increasing order. They default to DefaultBuckets.
+	Buckets []float64
+}
+
+// Collector aggregates instrumentation events. It is safe for concurrent use.
+type Collector struct {
+	buckets []float64
+
+	mu          sync.Mutex
+	components  map[string]*Stats
+	pages       map[string]*Stats
+	cacheHits   uint64
+	cacheMisses uint64
+}
+
+// Stats are the totals of one component or page.
+type Stats struct {
+	Renders uint64 `json:"renders"`
+	Errors  uint64 `json:"errors"`
+	Bytes   uint64 `json:"bytes"`
+	// Seconds is the total render time.
+	Seconds float64 `json:"seconds"`
+	// Buckets holds, for each histogram bound, how many renders took at
+	// most that long.
+	Buckets []uint64 `json:"buckets"`
+}
+
+// Snapshot is a copy of everything a Collector has counted.
+type Snapshot struct {
+	Buckets     []float64        `json:"bucket_bounds"`
+	Components  map[string]Stats `json:"components"`
+	Pages       map[string]Stats `json:"pages"`
+	CacheHits   uint64           `json:"cache_hits"`
+	CacheMisses uint64           `json:"cache_misses"`
+}
+
+func New(opts Options) *Collector {
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &Collector{
+		buckets:    slices.Clone(buckets),
+		components: make(map[string]*Stats),
+		pages:      make(map[string]*Stats),
+	}
+}
+
+// Option registers the collector with an engine for both component and
+// page events.
+func (c *Collector) Option() hc.Option {
+	return func(h *hc.HC) {
+		hc.WithComponentInstrumentation(c.Observe)(h)
+		hc.WithPageInstrumentation(c.Observe)(h)
+	}
+}
+
+// Observe records one instrumentation event. Option wires it up; pass it to
+// WithComponentInstrumentation and WithPageInstrumentation yourself to
+// combine the collector with other hooks.
+func (c *Collector) Observe(_ context.Context, evt hc.ComponentInstrumentationEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch evt.Stage {
+	case hc.ComponentStageBegin:
+		// Templates that failed to load have no source and say nothing
+		// about the cache.
+		if evt.Source == "" {
+			return
+		}
+		if evt.Cached {
+			c.cacheHits++
+		} else {
+			c.cacheMisses++
+		}
+	case hc.ComponentStageEnd:
+		c.record(c.components, evt.Component, evt)
+	case hc.PageStageEnd:
+		c.record(c.pages, evt.Source, evt)
+	}
+}
+
+func (c *Collector) record(series map[string]*Stats, name string, evt hc.ComponentInstrumentationEvent) {
+	stats := series[name]
+	if stats == nil {
+		stats = &Stats{Buckets: make([]uint64, len(c.buckets))}
+		series[name] = stats
+	}
+
+	stats.Renders++
+	if evt.Err != nil {
+		stats.Errors++
+	}
+	stats.Bytes += uint64(evt.Bytes)
+	seconds := evt.Duration.Seconds()
+	stats.Seconds += seconds
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			stats.Buckets[i]++
+		}
+	}
+}
+
+// Snapshot returns a copy of the current totals.
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Snapshot{
+		Buckets:     slices.Clone(c.buckets),
+		Components:  copyStats(c.components),
+		Pages:       copyStats(c.pages),
+		CacheHits:   c.cacheHits,
+		CacheMisses: c.cacheMisses,
+	}
+}
+
+func copyStats(series map[string]*Stats) map[string]Stats {
+	copied := make(map[string]Stats, len(series))
+	for name, stats := range series {
+		entry := *stats
+		entry.Buckets = slices.Clone(stats.Buckets)
+		copied[name] = entry
+	}
+	return copied
+}
+
+// Publish exposes the collector's snapshot as the expvar variable name. Like
+// expvar.Publish, it panics if the name is already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return c.Snapshot() }))
+}
+
+// Handler serves the totals in the Prometheus text exposition format.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.WriteTo(w)
+	})
+}
+
+// WriteTo writes the totals in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	snap := c.Snapshot()
+
+	var b strings.Builder
+	writeSeries(&b, "component", snap.Components, snap.Buckets)
+	writeSeries(&b, "page", snap.Pages, snap.Buckets)
+	writeMetric(&b, "hc_template_cache_hits_total", "counter", "Component templates served from the parse cache.")
+	fmt.Fprintf(&b, "hc_template_cache_hits_total %d\n", snap.CacheHits)
+	writeMetric(&b, "hc_template_cache_misses_total", "counter", "Component templates parsed because they were not cached.")
+	fmt.Fprintf(&b, "hc_template_cache_misses_total %d\n", snap.CacheMisses)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeSeries writes the counters and latency histogram of every component
+// or page, labelled with kind.
+func writeSeries(b *strings.Builder, kind string, series map[string]Stats, buckets []float64) {
+	names := slices.Sorted(maps.Keys(series))
+	prefix := "hc_" + kind + "_"
+
+	counters := []struct {
+		name, help string
+		value      func(Stats) uint64
+	}{
+		{"renders_total", "Renders by " + kind + ".", func(s Stats) uint64 { return s.Renders }},
+		{"errors_total", "Failed renders by " + kind + ".", func(s Stats) uint64 { return s.Errors }},
+		{"bytes_total", "Bytes produced by " + kind + ".", func(s Stats) uint64 { return s.Bytes }},
+	}
+	for _, counter := range counters {
+		writeMetric(b, prefix+counter.name, "counter", counter.help)
+		for _, name := range names {
+			fmt.Fprintf(b, "%s%s{%s=%s} %d\n", prefix, counter.name, kind, quoteLabel(name), counter.value(series[name]))
+		}
+	}
+
+	histogram := prefix + "render_seconds"
+	writeMetric(b, histogram, "histogram", "Render latency by "+kind+".")
+	for _, name := range names {
+		stats, label := series[name], kind+"="+quoteLabel(name)
+		for i, bound := range buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=%q} %d\n", histogram, label, strconv.FormatFloat(bound, 'g', -1, 64), stats.Buckets[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", histogram, label, stats.Renders)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", histogram, label, strconv.FormatFloat(stats.Seconds, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", histogram, label, stats.Renders)
+	}
+}
+
+func writeMetric(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/esrid/hc"
+)
+
+func renderTwice(t *testing.T, collector *Collector) {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"components/card.html":   {Data: []byte(`<section>{{ .Children }}</section>`)},
+		"components/broken.html": {Data: []byte(`{{ index .Props.items 3 }}`)},
+		"pages/home.gohtml":      {Data: []byte(`<Card>hi</Card>`)},
+		"pages/broken.gohtml":    {Data: []byte(`<Broken />`)},
+	}
+	engine := hc.NewHC("components", hc.WithFileSystem(fsys), collector.Option())
+	for range 2 {
+		if err := engine.ParseFileContext(context.Background(), nil, "pages/home.gohtml", nil); err != nil {
+			t.Fatalf("render home: %v", err)
+		}
+	}
+	if err := engine.ParseFileContext(context.Background(), nil, "pages/broken.gohtml", nil); err == nil {
+		t.Fatal("expected broken page to fail")
+	}
+}
+
+func TestCollectorCountsRenders(t *testing.T) {
+	t.Parallel()
+
+	collector := New(Options{Buckets: []float64{60}})
+	renderTwice(t, collector)
+
+	snap := collector.Snapshot()
+	card := snap.Components["Card"]
+	if card.Renders != 2 || card.Errors != 0 || card.Bytes != 2*uint64(len("<section>hi</section>")) || card.Buckets[0] != 2 {
+		t.Fatalf("unexpected Card stats: %+v", card)
+	}
+	if broken := snap.Components["Broken"]; broken.Renders != 1 || broken.Errors != 1 {
+		t.Fatalf("unexpected Broken stats: %+v", broken)
+	}
+	if home := snap.Pages["pages/home.gohtml"]; home.Renders != 2 || home.Bytes != card.Bytes {
+		t.Fatalf("unexpected page stats: %+v", home)
+	}
+	if snap.CacheHits != 1 || snap.CacheMisses != 2 {
+		t.Fatalf("cache hits/misses = %d/%d", snap.CacheHits, snap.CacheMisses)
+	}
+}
+
+func TestHandlerWritesPrometheusText(t *testing.T) {
+	t.Parallel()
+
+	collector := New(Options{Buckets: []float64{0.5, 60}})
+	collector.Observe(context.Background(), hc.ComponentInstrumentationEvent{Stage: hc.ComponentStageEnd, Component: "Card", Duration: time.Second, Bytes: 10})
+	collector.Observe(context.Background(), hc.ComponentInstrumentationEvent{Stage: hc.PageStageEnd, Source: `pages/"odd".gohtml`, Duration: time.Second})
+
+	rec := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE hc_component_renders_total counter\nhc_component_renders_total{component=\"Card\"} 1\n",
+		"hc_component_bytes_total{component=\"Card\"} 10\n",
+		"hc_component_render_seconds_bucket{component=\"Card\",le=\"0.5\"} 0\n",
+		"hc_component_render_seconds_bucket{component=\"Card\",le=\"60\"} 1\n",
+		"hc_component_render_seconds_bucket{component=\"Card\",le=\"+Inf\"} 1\n",
+		"hc_component_render_seconds_sum{component=\"Card\"} 1\n",
+		`hc_page_renders_total{page="pages/\"odd\".gohtml"} 1`,
+		"hc_template_cache_misses_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	collector := New(Options{})
+	collector.Publish("hc_metrics_test")
+	collector.Observe(context.Background(), hc.ComponentInstrumentationEvent{Stage: hc.ComponentStageBegin, Component: "Card", Source: "components/card.html", Cached: true})
+
+	if got := expvar.Get("hc_metrics_test").String(); !strings.Contains(got, `"cache_hits":1`) {
+		t.Fatalf("unexpected expvar value: %s", got)
+	}
+}
@@ -0,0 +1,439 @@
+// Package markup implements the HTML5-aware tokenizer hc uses to find
+// component tags. It never rewrites its input: every token carries the exact
+// byte offsets it was read from, so callers can copy untouched markup through
+// verbatim and only replace the ranges they care about.
+//
+// The tokenizer follows the HTML5 tokenization rules that matter for that job:
+// raw text and RCDATA elements (script, style, textarea, title, ...),
+// comments, doctypes and other declarations, and double-quoted, single-quoted,
+// unquoted and valueless attributes. Tag names keep the case they were written
+// in. Malformed markup never fails; it is reported as text.
+package markup
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	Text Kind = iota
+	StartTag
+	EndTag
+	SelfClosingTag
+	Comment
+	// Declaration covers doctypes, CDATA sections and processing instructions.
+	Declaration
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Text:
+		return "text"
+	case StartTag:
+		return "start tag"
+	case EndTag:
+		return "end tag"
+	case SelfClosingTag:
+		return "self-closing tag"
+	case Comment:
+		return "comment"
+	case Declaration:
+		return "declaration"
+	}
+	return "unknown"
+}
+
+type Attr struct {
+	Name string
+	// Value is the entity-decoded attribute value.
+	Value string
+	// HasValue is false for valueless attributes such as <input disabled>.
+	HasValue bool
+	// Start and End are the byte offsets of the whole attribute.
+	Start, End int
+}
+
+type Token struct {
+	Kind Kind
+	// Name is the tag name as written, for tags only.
+	Name  string
+	Attrs []Attr
+	// Start and End are the byte offsets of the token in the input.
+	Start, End int
+}
+
+// rawTextElements hold text up to their matching end tag instead of markup.
+var rawTextElements = map[string]struct{}{
+	"script":    {},
+	"style":     {},
+	"xmp":       {},
+	"iframe":    {},
+	"noembed":   {},
+	"noframes":  {},
+	"textarea":  {},
+	"title":     {},
+	"plaintext": {},
+}
+
+// IsRawText reports whether the element called name holds raw text or RCDATA.
+func IsRawText(name string) bool {
+	_, ok := rawTextElements[strings.ToLower(name)]
+	return ok
+}
+
+type Tokenizer struct {
+	input []byte
+	pos   int
+	// rawEnd is the lower-cased name of the raw text element whose content
+	// comes next, if any.
+	rawEnd string
+}
+
+func NewTokenizer(input []byte) *Tokenizer {
+	return &Tokenizer{input: input}
+}
+
+// Next returns the next token, or false once the input is exhausted.
+func (z *Tokenizer) Next() (Token, bool) {
+	if z.pos >= len(z.input) {
+		return Token{}, false
+	}
+
+	if z.rawEnd != "" {
+		name := z.rawEnd
+		z.rawEnd = ""
+		end := z.findRawEnd(name)
+		if end > z.pos {
+			tok := Token{Kind: Text, Start: z.pos, End: end}
+			z.pos = end
+			return tok, true
+		}
+	}
+
+	start := z.pos
+	if z.input[start] == '<' {
+		if tok, ok := z.readMarkup(); ok {
+			return tok, true
+		}
+		// A '<' that does not open markup is literal text.
+		z.pos = start + 1
+	}
+
+	end := z.nextMarkupStart(z.pos)
+	z.pos = end
+	return Token{Kind: Text, Start: start, End: end}, true
+}
+
+// nextMarkupStart returns the offset of the next '<' that opens markup.
+func (z *Tokenizer) nextMarkupStart(from int) int {
+	for i := from; i < len(z.input); i++ {
+		if z.input[i] != '<' || i+1 >= len(z.input) {
+			continue
+		}
+		c := z.input[i+1]
+		if isASCIILetter(c) || c == '!' || c == '/' || c == '?' {
+			return i
+		}
+	}
+	return len(z.input)
+}
+
+func (z *Tokenizer) readMarkup() (Token, bool) {
+	start := z.pos
+	rest := z.input[start:]
+	if len(rest) < 2 {
+		return Token{}, false
+	}
+
+	switch c := rest[1]; {
+	case c == '!':
+		return z.readDeclaration(), true
+	case c == '?':
+		end := z.indexFrom(start+2, ">", len(z.input))
+		z.pos = end
+		return Token{Kind: Declaration, Start: start, End: end}, true
+	case c == '/':
+		if len(rest) > 2 && isASCIILetter(rest[2]) {
+			return z.readTag(EndTag), true
+		}
+		if len(rest) > 2 && rest[2] == '>' {
+			z.pos = start + 3
+			return Token{Kind: Comment, Start: start, End: z.pos}, true
+		}
+		if len(rest) > 2 {
+			// Bogus comment such as "</ foo>".
+			end := z.indexFrom(start+2, ">", len(z.input))
+			z.pos = end
+			return Token{Kind: Comment, Start: start, End: end}, true
+		}
+		return Token{}, false
+	case isASCIILetter(c):
+		return z.readTag(StartTag), true
+	}
+	return Token{}, false
+}
+
+func (z *Tokenizer) readDeclaration() Token {
+	start := z.pos
+	rest := z.input[start:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("<!--")):
+		end := len(z.input)
+		if idx := z.commentEnd(start + 4); idx != -1 {
+			end = idx
+		}
+		z.pos = end
+		return Token{Kind: Comment, Start: start, End: end}
+	case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+		end := z.indexFrom(start+9, "]]>", len(z.input))
+		z.pos = end
+		return Token{Kind: Declaration, Start: start, End: end}
+	case len(rest) > 2 && isASCIILetter(rest[2]):
+		end := z.indexFrom(start+2, ">", len(z.input))
+		z.pos = end
+		return Token{Kind: Declaration, Start: start, End: end}
+	}
+	end := z.indexFrom(start+2, ">", len(z.input))
+	z.pos = end
+	return Token{Kind: Comment, Start: start, End: end}
+}
+
+// commentEnd returns the offset just past the end of a comment whose body
+// starts at from, honouring the "<!-->" and "--!>" forms.
+func (z *Tokenizer) commentEnd(from int) int {
+	if from < len(z.input) && z.input[from] == '>' {
+		return from + 1
+	}
+	if bytes.HasPrefix(z.input[from:], []byte("->")) {
+		return from + 2
+	}
+	for i := from; i < len(z.input); i++ {
+		if z.input[i] != '-' {
+			continue
+		}
+		if bytes.HasPrefix(z.input[i:], []byte("-->")) {
+			return i + 3
+		}
+		if bytes.HasPrefix(z.input[i:], []byte("--!>")) {
+			return i + 4
+		}
+	}
+	return -1
+}
+
+// indexFrom returns the offset just past the first sep at or after from, or
+// fallback when sep does not occur.
+func (z *Tokenizer) indexFrom(from int, sep string, fallback int) int {
+	if from > len(z.input) {
+		return fallback
+	}
+	idx := bytes.Index(z.input[from:], []byte(sep))
+	if idx == -1 {
+		return fallback
+	}
+	return from + idx + len(sep)
+}
+
+func (z *Tokenizer) readTag(kind Kind) Token {
+	start := z.pos
+	i := start + 1
+	if kind == EndTag {
+		i++
+	}
+
+	nameStart := i
+	for i < len(z.input) && !isSpace(z.input[i]) && z.input[i] != '/' && z.input[i] != '>' {
+		i++
+	}
+	tok := Token{Kind: kind, Name: string(z.input[nameStart:i]), Start: start}
+
+	seen := make(map[string]struct{})
+	for {
+		for i < len(z.input) && (isSpace(z.input[i]) || (z.input[i] == '/' && !z.selfClosingAt(i))) {
+			i++
+		}
+		if i >= len(z.input) {
+			// EOF inside a tag: the partial tag is reported as text.
+			z.pos = len(z.input)
+			return Token{Kind: Text, Start: start, End: len(z.input)}
+		}
+		if z.input[i] == '>' {
+			i++
+			break
+		}
+		if z.selfClosingAt(i) {
+			if kind == StartTag {
+				tok.Kind = SelfClosingTag
+			}
+			i += 2
+			break
+		}
+
+		attr, next := z.readAttr(i)
+		i = next
+		if kind != StartTag {
+			continue
+		}
+		key := strings.ToLower(attr.Name)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		tok.Attrs = append(tok.Attrs, attr)
+	}
+
+	tok.End = i
+	z.pos = i
+	// Capitalised names are components, not HTML elements, even when they
+	// share a name with one, as <Title> does.
+	if tok.Kind == StartTag && !isUpper(tok.Name[0]) && IsRawText(tok.Name) {
+		z.rawEnd = strings.ToLower(tok.Name)
+	}
+	return tok
+}
+
+func (z *Tokenizer) selfClosingAt(i int) bool {
+	return z.input[i] == '/' && i+1 < len(z.input) && z.input[i+1] == '>'
+}
+
+func (z *Tokenizer) readAttr(i int) (Attr, int) {
+	attr := Attr{Start: i}
+
+	nameStart := i
+	// A leading '=' is part of the name, as in the HTML5 tokenizer.
+	if z.input[i] == '=' {
+		i++
+	}
+	for i < len(z.input) && !isSpace(z.input[i]) && z.input[i] != '/' && z.input[i] != '>' && z.input[i] != '=' {
+		i++
+	}
+	attr.Name = string(z.input[nameStart:i])
+
+	j := i
+	for j < len(z.input) && isSpace(z.input[j]) {
+		j++
+	}
+	if j >= len(z.input) || z.input[j] != '=' {
+		attr.End = i
+		return attr, i
+	}
+	j++
+	for j < len(z.input) && isSpace(z.input[j]) {
+		j++
+	}
+	if j >= len(z.input) {
+		attr.End = j
+		return attr, j
+	}
+
+	attr.HasValue = true
+	switch q := z.input[j]; q {
+	case '"', '\'':
+		end := bytes.IndexByte(z.input[j+1:], q)
+		if end == -1 {
+			attr.Value = html.UnescapeString(string(z.input[j+1:]))
+			attr.End = len(z.input)
+			return attr, len(z.input)
+		}
+		valueEnd := j + 1 + end
+		attr.Value = html.UnescapeString(string(z.input[j+1 : valueEnd]))
+		attr.End = valueEnd + 1
+		return attr, valueEnd + 1
+	default:
+		k := j
+		for k < len(z.input) && !isSpace(z.input[k]) && z.input[k] != '>' {
+			k++
+		}
+		attr.Value = html.UnescapeString(string(z.input[j:k]))
+		attr.End = k
+		return attr, k
+	}
+}
+
+// findRawEnd returns the offset of the end tag closing the raw text element
+// name, or the end of input when it is never closed.
+func (z *Tokenizer) findRawEnd(name string) int {
+	for i := z.pos; i < len(z.input); i++ {
+		if z.input[i] != '<' || i+1 >= len(z.input) || z.input[i+1] != '/' {
+			continue
+		}
+		nameEnd := i + 2 + len(name)
+		if nameEnd > len(z.input) {
+			break
+		}
+		if !strings.EqualFold(string(z.input[i+2:nameEnd]), name) {
+			continue
+		}
+		if nameEnd == len(z.input) || isSpace(z.input[nameEnd]) || z.input[nameEnd] == '/' || z.input[nameEnd] == '>' {
+			return i
+		}
+	}
+	return len(z.input)
+}
+
+// Position converts a byte offset into a 1-based line and column. Columns
+// count runes, so multi-byte characters advance them by one.
+func Position(input []byte, offset int) (line, col int) {
+	if offset > len(input) {
+		offset = len(input)
+	}
+	before := input[:offset]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	col = utf8.RuneCount(before[lineStart:]) + 1
+	return line, col
+}
+
+// Positioner converts increasing byte offsets into line and column numbers
+// without rescanning the input from the start for every lookup.
+type Positioner struct {
+	input  []byte
+	offset int
+	line   int
+	col    int
+}
+
+func NewPositioner(input []byte) *Positioner {
+	return &Positioner{input: input, line: 1, col: 1}
+}
+
+// At returns the 1-based line and column of offset. Offsets smaller than the
+// previous call restart the scan from the beginning.
+func (p *Positioner) At(offset int) (line, col int) {
+	if offset > len(p.input) {
+		offset = len(p.input)
+	}
+	if offset < p.offset {
+		p.offset, p.line, p.col = 0, 1, 1
+	}
+	for p.offset < offset {
+		r, size := utf8.DecodeRune(p.input[p.offset:])
+		if size == 0 {
+			break
+		}
+		if r == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
+		p.offset += size
+	}
+	return p.line, p.col
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f'
+}
+
+func isUpper(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,126 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+func collect(t *testing.T, input string) []Token {
+	t.Helper()
+	z := NewTokenizer([]byte(input))
+	var tokens []Token
+	end := 0
+	for {
+		tok, ok := z.Next()
+		if !ok {
+			break
+		}
+		if tok.Start != end {
+			t.Fatalf("token %v starts at %d, want %d (tokens must tile the input)", tok.Kind, tok.Start, end)
+		}
+		end = tok.End
+		tokens = append(tokens, tok)
+	}
+	if end != len(input) {
+		t.Fatalf("tokens end at %d, want %d", end, len(input))
+	}
+	return tokens
+}
+
+func TestTokenizer_Attributes(t *testing.T) {
+	t.Parallel()
+
+	input := `<Button disabled label=Save title='a "b"' data-x = "1 &amp; 2" label="dup"/>`
+	tokens := collect(t, input)
+	if len(tokens) != 1 || tokens[0].Kind != SelfClosingTag || tokens[0].Name != "Button" {
+		t.Fatalf("unexpected tokens: %+v", tokens)
+	}
+
+	want := []Attr{
+		{Name: "disabled"},
+		{Name: "label", Value: "Save", HasValue: true},
+		{Name: "title", Value: `a "b"`, HasValue: true},
+		{Name: "data-x", Value: "1 & 2", HasValue: true},
+	}
+	got := tokens[0].Attrs
+	if len(got) != len(want) {
+		t.Fatalf("got %d attrs, want %d: %+v", len(got), len(want), got)
+	}
+	for i, attr := range want {
+		if got[i].Name != attr.Name || got[i].Value != attr.Value || got[i].HasValue != attr.HasValue {
+			t.Fatalf("attr %d = %+v, want %+v", i, got[i], attr)
+		}
+		if raw := input[got[i].Start:got[i].End]; !strings.HasPrefix(raw, attr.Name) {
+			t.Fatalf("attr %d offsets cover %q", i, raw)
+		}
+	}
+}
+
+func TestTokenizer_RawTextAndComments(t *testing.T) {
+	t.Parallel()
+
+	input := `<script>if (a<b && c>d) { x("</div>") }</SCRIPT><!-- <Card/> --><textarea><Card></textarea><p>1 < 2 &nbsp;</p>`
+	tokens := collect(t, input)
+
+	var kinds []string
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.Kind.String()+":"+tok.Name)
+	}
+	got := strings.Join(kinds, ",")
+	want := "start tag:script,text:,end tag:SCRIPT,comment:,start tag:textarea,text:,end tag:textarea,start tag:p,text:,end tag:p"
+	if got != want {
+		t.Fatalf("token kinds mismatch\nwant: %s\ngot:  %s", want, got)
+	}
+}
+
+func TestTokenizer_ComponentsNamedLikeRawTextElements(t *testing.T) {
+	t.Parallel()
+
+	tokens := collect(t, `<Title><Script>a</Script></Title>`)
+	var kinds []string
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.Kind.String()+":"+tok.Name)
+	}
+	got := strings.Join(kinds, ",")
+	want := "start tag:Title,start tag:Script,text:,end tag:Script,end tag:Title"
+	if got != want {
+		t.Fatalf("token kinds mismatch\nwant: %s\ngot:  %s", want, got)
+	}
+}
+
+func TestTokenizer_MalformedMarkupIsText(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"a < b", "<", "x <3 y", "<div class=", "<!-- open"} {
+		tokens := collect(t, input)
+		for _, tok := range tokens {
+			if tok.Kind == StartTag || tok.Kind == EndTag || tok.Kind == SelfClosingTag {
+				t.Fatalf("input %q produced tag token %+v", input, tok)
+			}
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("ab\nçd<X>")
+	line, col := Position(input, strings.Index(string(input), "<X>"))
+	if line != 2 || col != 3 {
+		t.Fatalf("Position = %d:%d, want 2:3", line, col)
+	}
+}
+
+func TestPositioner(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("<A>\n  <B/>ü<C/>\n<D/>")
+	p := NewPositioner(input)
+	for _, name := range []string{"<B/>", "<C/>", "<D/>", "<A>"} {
+		offset := strings.Index(string(input), name)
+		wantLine, wantCol := Position(input, offset)
+		if line, col := p.At(offset); line != wantLine || col != wantCol {
+			t.Fatalf("At(%s) = %d:%d, want %d:%d", name, line, col, wantLine, wantCol)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+// Package yaml decodes the subset of YAML used for page fixtures: block
+// mappings and sequences, flow collections, plain, quoted and block scalars,
+// and comments. Anchors, tags and multiple documents are not supported.
+//
+// Decoded values have the shapes encoding/json produces for an any, except
+// that integers decode to int: map[string]any, []any, string, bool, int,
+// float64 and nil.
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type line struct {
+	num    int
+	indent int
+	text   string
+}
+
+// Unmarshal decodes a single YAML document.
+func Unmarshal(data []byte) (any, error) {
+	p := &parser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		p.lines = append(p.lines, line{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) && p.lines[p.pos].text == "---" {
+		p.pos++
+		p.skipBlank()
+	}
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+
+	value, err := p.parseBlock(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected content", p.lines[p.pos].num)
+	}
+	return value, nil
+}
+
+type parser struct {
+	lines []line
+	pos   int
+}
+
+// skipBlank moves past empty and comment-only lines.
+func (p *parser) skipBlank() {
+	for p.pos < len(p.lines) {
+		text := p.lines[p.pos].text
+		if text != "" && !strings.HasPrefix(text, "#") {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseBlock(indent int) (any, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *parser) parseSequence(indent int) ([]any, error) {
+	items := []any{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return items, nil
+		}
+		ln := p.lines[p.pos]
+		// A sequence nested at its key's indentation ends at the next key.
+		if ln.indent < indent || (ln.indent == indent && !isSequenceItem(ln.text)) {
+			return items, nil
+		}
+		if ln.indent > indent {
+			return nil, fmt.Errorf("line %d: bad indentation in sequence", ln.num)
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(ln.text, "-"), " ")
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.pos++
+			value, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+
+		// "- key: value" opens a mapping whose keys line up with key, and
+		// "- - x" a nested sequence; both continue on the following lines.
+		if isSequenceItem(rest) || mappingKey(rest) != "" {
+			p.lines[p.pos] = line{num: ln.num, indent: ln.indent + len(ln.text) - len(rest), text: rest}
+			value, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+
+		p.pos++
+		value, err := p.parseValue(ln, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+}
+
+func (p *parser) parseMapping(indent int) (map[string]any, error) {
+	values := map[string]any{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return values, nil
+		}
+		ln := p.lines[p.pos]
+		if ln.indent < indent {
+			return values, nil
+		}
+		if ln.indent > indent {
+			return nil, fmt.Errorf("line %d: bad indentation in mapping", ln.num)
+		}
+
+		key := mappingKey(ln.text)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", ln.num)
+		}
+		rest := strings.TrimLeft(ln.text[len(key)+1:], " ")
+		name, err := parseKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln.num, err)
+		}
+		if _, dup := values[name]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", ln.num, name)
+		}
+
+		p.pos++
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			value, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = value
+			continue
+		}
+		value, err := p.parseValue(ln, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+}
+
+// parseNested parses the block following a key or dash with nothing after
+// it. A sequence may sit at the same indentation as its mapping key.
+func (p *parser) parseNested(indent int) (any, error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	ln := p.lines[p.pos]
+	if ln.indent > indent || (ln.indent == indent && isSequenceItem(ln.text)) {
+		return p.parseBlock(ln.indent)
+	}
+	return nil, nil
+}
+
+// mappingKey returns the key part of "key: value" or "key:", or "" when text
+// is not a mapping entry. Flow collections are values even when they hold a
+// colon, as in {name: a}.
+func mappingKey(text string) string {
+	if text == "" || text[0] == '{' || text[0] == '[' {
+		return ""
+	}
+	if q := text[0]; q == '"' || q == '\'' {
+		end := closingQuote(text, q)
+		if end == -1 || end+1 >= len(text) || text[end+1] != ':' {
+			return ""
+		}
+		if end+2 < len(text) && text[end+2] != ' ' {
+			return ""
+		}
+		return text[:end+1]
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return text[:i]
+		}
+		if text[i] == '#' && i > 0 && text[i-1] == ' ' {
+			return ""
+		}
+	}
+	return ""
+}
+
+func parseKey(key string) (string, error) {
+	if key[0] == '"' || key[0] == '\'' {
+		value, err := parseQuoted(key)
+		if err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// parseValue parses the value written after a key or dash on ln.
+func (p *parser) parseValue(ln line, text string, indent int) (any, error) {
+	switch {
+	case text == "|" || text == "|-" || text == "|+" || text == ">" || text == ">-" || text == ">+":
+		return p.parseBlockScalar(text, indent), nil
+	case text[0] == '[' || text[0] == '{':
+		value, rest, err := parseFlow(stripComment(text))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln.num, err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after flow collection", ln.num, rest)
+		}
+		return value, nil
+	case text[0] == '"' || text[0] == '\'':
+		end := closingQuote(text, text[0])
+		if end == -1 {
+			return nil, fmt.Errorf("line %d: unterminated string", ln.num)
+		}
+		if rest := strings.TrimSpace(text[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after string", ln.num, rest)
+		}
+		value, err := parseQuoted(text[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", ln.num, err)
+		}
+		return value, nil
+	}
+	return parsePlain(stripComment(text)), nil
+}
+
+// parseBlockScalar reads the indented lines of a "|" or ">" scalar.
+func (p *parser) parseBlockScalar(header string, indent int) string {
+	var body []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		ln := p.lines[p.pos]
+		if ln.text == "" {
+			body = append(body, "")
+			p.pos++
+			continue
+		}
+		if ln.indent <= indent {
+			break
+		}
+		if blockIndent == -1 {
+			blockIndent = ln.indent
+		}
+		body = append(body, strings.Repeat(" ", max(ln.indent-blockIndent, 0))+ln.text)
+		p.pos++
+	}
+
+	trailing := 0
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+		trailing++
+	}
+
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(body, "\n")
+	} else {
+		var b strings.Builder
+		for i, l := range body {
+			switch {
+			case i == 0:
+			case l == "" || body[i-1] == "":
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(l)
+		}
+		text = b.String()
+	}
+
+	switch {
+	case strings.HasSuffix(header, "-") || text == "":
+		return text
+	case strings.HasSuffix(header, "+"):
+		return text + strings.Repeat("\n", trailing+1)
+	}
+	return text + "\n"
+}
+
+func parseFlow(text string) (any, string, error) {
+	text = strings.TrimLeft(text, " ")
+	if text == "" {
+		return nil, "", fmt.Errorf("unexpected end of flow collection")
+	}
+	switch text[0] {
+	case '[':
+		items := []any{}
+		rest := strings.TrimLeft(text[1:], " ")
+		if strings.HasPrefix(rest, "]") {
+			return items, rest[1:], nil
+		}
+		for {
+			value, next, err := parseFlowItem(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, value)
+			next = strings.TrimLeft(next, " ")
+			switch {
+			case strings.HasPrefix(next, ","):
+				rest = next[1:]
+			case strings.HasPrefix(next, "]"):
+				return items, next[1:], nil
+			default:
+				return nil, "", fmt.Errorf("expected ',' or ']' in flow sequence")
+			}
+		}
+	case '{':
+		values := map[string]any{}
+		rest := strings.TrimLeft(text[1:], " ")
+		if strings.HasPrefix(rest, "}") {
+			return values, rest[1:], nil
+		}
+		for {
+			key, next, err := parseFlowItem(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			next = strings.TrimLeft(next, " ")
+			if !strings.HasPrefix(next, ":") {
+				return nil, "", fmt.Errorf("expected ':' in flow mapping")
+			}
+			value, after, err := parseFlowItem(next[1:])
+			if err != nil {
+				return nil, "", err
+			}
+			values[fmt.Sprint(key)] = value
+			after = strings.TrimLeft(after, " ")
+			switch {
+			case strings.HasPrefix(after, ","):
+				rest = after[1:]
+			case strings.HasPrefix(after, "}"):
+				return values, after[1:], nil
+			default:
+				return nil, "", fmt.Errorf("expected ',' or '}' in flow mapping")
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("expected flow collection")
+}
+
+func parseFlowItem(text string) (any, string, error) {
+	text = strings.TrimLeft(text, " ")
+	if text == "" {
+		return nil, "", fmt.Errorf("unexpected end of flow collection")
+	}
+	switch q := text[0]; q {
+	case '[', '{':
+		return parseFlow(text)
+	case '"', '\'':
+		end := closingQuote(text, q)
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		value, err := parseQuoted(text[:end+1])
+		return value, text[end+1:], err
+	}
+	end := strings.IndexAny(text, ",]}")
+	if end == -1 {
+		end = len(text)
+	}
+	// A colon followed by a space ends a flow mapping key.
+	if idx := strings.Index(text[:end], ": "); idx != -1 {
+		end = idx
+	}
+	return parsePlain(strings.TrimSpace(text[:end])), text[end:], nil
+}
+
+// closingQuote returns the index of the quote closing the string that opens
+// text, or -1.
+func closingQuote(text string, q byte) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case q == '"' && text[i] == '\\':
+			i++
+		case text[i] == q:
+			if q == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func parseQuoted(text string) (string, error) {
+	if text[0] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	value, err := strconv.Unquote(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", text)
+	}
+	return value, nil
+}
+
+func stripComment(text string) string {
+	if idx := strings.Index(text, " #"); idx != -1 {
+		text = text[:idx]
+	}
+	return strings.TrimSpace(text)
+}
+
+func parsePlain(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+	if isNumeric(text) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+	return text
+}
+
+// isNumeric rejects the words ParseFloat accepts ("inf", "nan") and hex
+// floats so they stay strings.
+func isNumeric(text string) bool {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if (c < '0' || c > '9') && c != '.' && c != '-' && c != '+' && c != 'e' && c != 'E' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	input := `---
+# page fixture
+title: "Pricing: plans"
+draft: false
+weight: 3
+ratio: 0.5
+missing: ~
+hero:
+  heading: Simple pricing   # trailing comment
+  cta: { label: Start, href: /signup }
+tags: [a, "b c", 2]
+plans:
+  - name: Free
+    features:
+      - 1 project
+      - 'Community ''forum'''
+  - name: Pro
+    price: 12
+links:
+- http://example.com/a#b
+body: |
+  First line
+    indented
+
+  After blank
+summary: >-
+  Folded
+  text
+`
+	got, err := Unmarshal([]byte(input))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := map[string]any{
+		"title":   "Pricing: plans",
+		"draft":   false,
+		"weight":  3,
+		"ratio":   0.5,
+		"missing": nil,
+		"hero": map[string]any{
+			"heading": "Simple pricing",
+			"cta":     map[string]any{"label": "Start", "href": "/signup"},
+		},
+		"tags": []any{"a", "b c", 2},
+		"plans": []any{
+			map[string]any{"name": "Free", "features": []any{"1 project", "Community 'forum'"}},
+			map[string]any{"name": "Pro", "price": 12},
+		},
+		"links":   []any{"http://example.com/a#b"},
+		"body":    "First line\n  indented\n\nAfter blank\n",
+		"summary": "Folded text",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected value:\n%#v\nwant:\n%#v", got, want)
+	}
+}
+
+func TestUnmarshal_FlowCollectionsInBlockSequences(t *testing.T) {
+	t.Parallel()
+
+	input := `items:
+  - {name: a, n: 1}
+  - [a, {b: c}]
+  - - {x: "y: z"}
+`
+	got, err := Unmarshal([]byte(input))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]any{"items": []any{
+		map[string]any{"name": "a", "n": 1},
+		[]any{"a", map[string]any{"b": "c"}},
+		[]any{map[string]any{"x": "y: z"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected value:\n%#v\nwant:\n%#v", got, want)
+	}
+
+	top, err := Unmarshal([]byte("- {name: a}\n- [1, 2]\n"))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if want := []any{map[string]any{"name": "a"}, []any{1, 2}}; !reflect.DeepEqual(top, want) {
+		t.Fatalf("unexpected value:\n%#v\nwant:\n%#v", top, want)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"a: 1\n  b: 2\n", "line 2: bad indentation"},
+		{"a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"a: \"open\n", "line 1: unterminated string"},
+		{"just text\n", `line 1: expected "key: value"`},
+	} {
+		_, err := Unmarshal([]byte(tc.input))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Unmarshal(%q) error = %v, want %q", tc.input, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package hc
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/esrid/hc/internal/markup"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a page or component file, by Lint or
+// while rendering (see WithDiagnostics).
+type Diagnostic struct {
+	Severity  Severity
+	Component string
+	Origin    Origin
+	Message   string
+}
+
+func (d Diagnostic) String() string {
+	if origin := d.Origin.String(); origin != "" {
+		return fmt.Sprintf("%s: %s: %s", origin, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+type LintOption func(*lintConfig)
+
+type lintConfig struct {
+	skipFuncCheck bool
+}
+
+// LintSkipFuncCheck accepts calls to template functions the engine does not
+// know about, for linting without the application's func map.
+func LintSkipFuncCheck() LintOption {
+	return func(cfg *lintConfig) {
+		cfg.skipFuncCheck = true
+	}
+}
+
+// templateBuiltins are the functions text/template predefines. Parsing with
+// parse.Tree directly has to be told about them.
+var templateBuiltins = map[string]any{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true, "eq": true, "ge": true,
+	"gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// templateErrorLine extracts the line and message from a text/template parse
+// error ("template: name:12: unexpected ...").
+var templateErrorLine = regexp.MustCompile(`(?s)^template: [^:]*:(\d+): (.*)$`)
+
+// Lint checks pages and every file in the component folder without rendering
+// anything. It reports unknown component tags, attributes that break the
+// WithAttrRules or @props rules, unclosed component tags, pages, component
+// templates and attribute expressions that fail to parse, components no page
+// reaches and component cycles. Unused components are only reported when
+// pages are given.
+//
+// The returned error is reserved for files that cannot be read.
+func (h *HC) Lint(ctx context.Context, pages []string, opts ...LintOption) ([]Diagnostic, error) {
+	var cfg lintConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	l := &linter{
+		h:          h,
+		components: make(map[string]*lintComponent),
+		roots:      make(map[string]struct{}),
+	}
+	if !cfg.skipFuncCheck {
+		l.funcs = h.componentFuncMap(h.mergedFuncMap(ctx))
+	}
+
+	files, err := h.componentFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		l.component(componentNameFromFile(file), file)
+	}
+
+	for _, page := range pages {
+		content, err := h.readFile(page)
+		if err != nil {
+			return nil, err
+		}
+		l.scan(content, Origin{File: page, Line: 1, Column: 1}, "")
+		l.parsePage(page, content)
+	}
+
+	// Scanning a component can discover components outside the listing, so
+	// keep going until nothing new turns up.
+	for {
+		pending := l.pending()
+		if pending == nil {
+			break
+		}
+		pending.scanned = true
+		l.lintComponent(pending)
+	}
+
+	l.checkCycles()
+	if len(pages) > 0 {
+		l.checkUnused()
+	}
+
+	slices.SortStableFunc(l.diags, func(a, b Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.Origin.File, b.Origin.File),
+			cmp.Compare(a.Origin.Line, b.Origin.Line),
+			cmp.Compare(a.Origin.Column, b.Origin.Column),
+		)
+	})
+	return l.diags, nil
+}
+
+type linter struct {
+	h *HC
+	// funcs is nil when function names are not checked.
+	funcs      map[string]any
+	components map[string]*lintComponent
+	// roots holds the components pages invoke directly.
+	roots map[string]struct{}
+	diags []Diagnostic
+}
+
+// lintComponent is a component file, keyed in linter.components by its
+// lower-cased path.
+type lintComponent struct {
+	name    string
+	source  string
+	edges   []lintEdge
+	scanned bool
+}
+
+// lintEdge is an invocation of component target from another component file.
+type lintEdge struct {
+	target string
+	origin Origin
+}
+
+func (l *linter) component(name, source string) string {
+	key := strings.ToLower(source)
+	if _, ok := l.components[key]; !ok {
+		l.components[key] = &lintComponent{name: name, source: source}
+	}
+	return key
+}
+
+func (l *linter) pending() *lintComponent {
+	keys := make([]string, 0, len(l.components))
+	for key, comp := range l.components {
+		if !comp.scanned {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.Sort(keys)
+	return l.components[keys[0]]
+}
+
+func (l *linter) report(severity Severity, component string, origin Origin, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Severity:  severity,
+		Component: component,
+		Origin:    origin,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintComponent(comp *lintComponent) {
+	content, err := l.h.readFile(comp.source)
+	if err != nil {
+		l.report(SeverityError, comp.name, Origin{File: comp.source}, "read component %s: %v", comp.name, err)
+		return
+	}
+	l.parseTemplate(comp, content)
+	l.scan(content, Origin{File: comp.source, Line: 1, Column: 1}, strings.ToLower(comp.source))
+}
+
+func (l *linter) parseTemplate(comp *lintComponent, content []byte) {
+	line, msg := l.parseError(comp.name, string(content), l.h.componentDelims(comp.name))
+	if msg != "" {
+		l.report(SeverityError, comp.name, Origin{File: comp.source, Line: line}, "parse component %s: %s", comp.name, msg)
+	}
+}
+
+// parsePage reports a page that fails to parse as a template, unless the
+// error sits on a line already reported, such as a broken attribute
+// expression.
+func (l *linter) parsePage(page string, content []byte) {
+	line, msg := l.parseError(page, string(content), l.h.cfg.delims)
+	if msg == "" {
+		return
+	}
+	for _, diag := range l.diags {
+		if diag.Severity == SeverityError && diag.Origin.File == page && diag.Origin.Line == line {
+			return
+		}
+	}
+	l.report(SeverityError, "", Origin{File: page, Line: line}, "parse page: %s", msg)
+}
+
+// parseError parses text as a template and returns the line and message of
+// the parse error, or an empty message.
+func (l *linter) parseError(name, text string, d delims) (int, string) {
+	tree := parse.New(name)
+	tree.Mode = parse.ParseComments
+	var funcs []map[string]any
+	if l.funcs == nil {
+		tree.Mode |= parse.SkipFuncCheck
+	} else {
+		funcs = []map[string]any{templateBuiltins, l.funcs}
+	}
+	_, err := tree.Parse(text, d.left, d.right, make(map[string]*parse.Tree), funcs...)
+	if err == nil {
+		return 0, ""
+	}
+	msg := err.Error()
+	line := 0
+	if m := templateErrorLine.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = m[2]
+	}
+	return line, msg
+}
+
+// scan checks every component tag in input, which starts at origin. from is
+// the lower-cased path of the component file input belongs to, or "" for a
+// page.
+func (l *linter) scan(input []byte, origin Origin, from string) {
+	positions := markup.NewPositioner(input)
+	err := walkMarkup(input,
+		func([]byte) error {
+			return nil
+		},
+		func(tag componentTag) error {
+			l.checkTag(tag, origin.advance(positions.At(tag.Offset)), from)
+			l.scan(tag.Children, origin.advance(positions.At(tag.ChildrenOffset)), from)
+			return nil
+		},
+	)
+
+	var unclosed *unclosedTagError
+	if errors.As(err, &unclosed) {
+		l.report(SeverityError, unclosed.name, origin.advance(positions.At(unclosed.offset)), "%v", unclosed)
+	}
+}
+
+func (l *linter) checkTag(tag componentTag, origin Origin, from string) {
+	// Actions in component files belong to the component template, which
+	// parseTemplate checks, and run before the tag is read, so
+	// {{ if .Props.big }}big{{ end }} may stand where attributes go.
+	d := l.h.cfg.delims
+	if from == "" {
+		l.checkAttrExprs(tag, origin)
+	} else {
+		d = l.h.componentDelims(l.components[from].name)
+	}
+	if _, ok := l.h.builtin(tag.Name); ok {
+		return
+	}
+
+	src, err := l.h.lookupComponentSource(tag.Name)
+	if err != nil {
+		l.report(SeverityError, tag.Name, origin, "unknown component %s", tag.Name)
+		return
+	}
+	key := l.component(tag.Name, src.source)
+	if from == "" {
+		l.roots[key] = struct{}{}
+	} else {
+		l.components[from].edges = append(l.components[from].edges, lintEdge{target: key, origin: origin})
+	}
+
+	names := make([]string, 0, len(tag.Attrs))
+	spread, actions := false, false
+	for _, attr := range tag.Attrs {
+		if isSpreadAttr(attr.Name) {
+			spread = true
+			continue
+		}
+		if strings.Contains(attr.Name, d.left) || strings.Contains(attr.Name, d.right) {
+			actions = true
+			continue
+		}
+		names = append(names, strings.ToLower(attr.Name))
+	}
+
+	renames := make(map[string]string)
+	for _, dep := range l.h.componentDeprecations(tag.Name).find(tag.Name, names) {
+		l.report(SeverityWarning, tag.Name, origin, "%s", dep.describe())
+		if dep.RenamedTo != "" {
+			renames[dep.Attr] = dep.RenamedTo
+		}
+	}
+	for i, name := range names {
+		if to, ok := renames[name]; ok {
+			names[i] = to
+		}
+	}
+
+	policy, ok := l.h.attrPolicy(tag.Name)
+	if !ok {
+		return
+	}
+	missing, unsupported := policy.check(names)
+	if spread || actions {
+		// The spread map may supply anything, so only what is spelled out
+		// can be checked.
+		missing = nil
+	}
+	if actions {
+		// Actions among the attributes split into words that are not
+		// attribute names, and may add or drop attributes.
+		unsupported = nil
+	}
+	for _, name := range missing {
+		if _, ok := policy.defaults[name]; !ok {
+			l.report(SeverityError, tag.Name, origin, "component %s missing required attr %q", tag.Name, name)
+		}
+	}
+	for _, name := range unsupported {
+		l.report(SeverityError, tag.Name, origin, "component %s received unsupported attr %q", tag.Name, name)
+	}
+	if !spread && !actions {
+		present := func(name string) bool { return slices.Contains(names, name) }
+		if problem := policy.checkGroups(present); problem != "" {
+			l.report(SeverityError, tag.Name, origin, "component %s %s", tag.Name, problem)
+		}
+	}
+
+	// Values without actions are known now, so their rules can run too.
+	literals := make(map[string]any)
+	for _, attr := range tag.Attrs {
+		if strings.Contains(attr.Name, d.left) || strings.Contains(attr.Name, d.right) {
+			continue
+		}
+		name := strings.ToLower(attr.Name)
+		if to, ok := renames[name]; ok {
+			name = to
+		}
+		switch {
+		case !attr.HasValue:
+			literals[name] = true
+		case !strings.Contains(attr.Value, d.left):
+			literals[name] = interpretAttrValue(attr.Value)
+		}
+	}
+	if name, err := policy.checkValues(literals); err != nil {
+		l.report(SeverityError, tag.Name, origin, "component %s attr %q: %v", tag.Name, name, err)
+	}
+}
+
+// checkAttrExprs parses the attribute values of tag that contain actions.
+func (l *linter) checkAttrExprs(tag componentTag, origin Origin) {
+	d := l.h.cfg.delims
+	for _, attr := range tag.Attrs {
+		if !attr.HasValue || !strings.Contains(attr.Value, d.left) {
+			continue
+		}
+		if _, msg := l.parseError("attr", attr.Value, d); msg != "" {
+			l.report(SeverityError, tag.Name, origin, "component %s attr %q: %s", tag.Name, attr.Name, msg)
+		}
+	}
+}
+
+// checkCycles reports every cycle in the component graph once, at the
+// invocation that closes it.
+func (l *linter) checkCycles() {
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make(map[string]int, len(l.components))
+	var stack []string
+	seen := make(map[string]struct{})
+
+	var visit func(key string)
+	visit = func(key string) {
+		state[key] = active
+		stack = append(stack, key)
+		for _, edge := range l.components[key].edges {
+			switch state[edge.target] {
+			case unvisited:
+				visit(edge.target)
+			case active:
+				cycle := stack[slices.Index(stack, edge.target):]
+				id := cycleID(cycle)
+				if _, ok := seen[id]; ok {
+					continue
+				}
+				seen[id] = struct{}{}
+				names := make([]string, 0, len(cycle)+1)
+				for _, k := range cycle {
+					names = append(names, l.components[k].name)
+				}
+				names = append(names, l.components[edge.target].name)
+				l.report(SeverityWarning, l.components[key].name, edge.origin, "component cycle: %s", strings.Join(names, " → "))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+	}
+
+	keys := make([]string, 0, len(l.components))
+	for key := range l.components {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if state[key] == unvisited {
+			visit(key)
+		}
+	}
+}
+
+// cycleID identifies a cycle independently of where the walk entered it.
+func cycleID(cycle []string) string {
+	start := 0
+	for i, key := range cycle {
+		if key < cycle[start] {
+			start = i
+		}
+	}
+	return strings.Join(append(slices.Clone(cycle[start:]), cycle[:start]...), "\x00")
+}
+
+func (l *linter) checkUnused() {
+	reached := make(map[string]struct{})
+	var queue []string
+	for key := range l.roots {
+		reached[key] = struct{}{}
+		queue = append(queue, key)
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, edge := range l.components[key].edges {
+			if _, ok := reached[edge.target]; !ok {
+				reached[edge.target] = struct{}{}
+				queue = append(queue, edge.target)
+			}
+		}
+	}
+
+	for _, comp := range l.components {
+		if _, ok := reached[strings.ToLower(comp.source)]; !ok {
+			l.report(SeverityWarning, comp.name, Origin{File: comp.source, Line: 1, Column: 1}, "component %s is never used", comp.name)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package hc
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// mergeAttrs renders the component's default attributes together with the
+// caller's, as forwardAttrs would:
+//
+//	<section{{ mergeAttrs .Attrs (dict "class" "card" "role" "region") "title" }}>
+//
+// Classes and styles from both sides are combined into a single attribute,
+// defaults first; for any other attribute the caller's value replaces the
+// default. Names in exclude are left out of the caller's attributes.
+func (h *HC) mergeAttrs(attrs []resolvedAttr, defaults map[string]any, exclude ...string) template.HTMLAttr {
+	skip := make(map[string]struct{}, len(exclude))
+	for _, name := range exclude {
+		skip[strings.ToLower(name)] = struct{}{}
+	}
+
+	merged := make([]resolvedAttr, 0, len(defaults)+len(attrs))
+	index := make(map[string]int, len(defaults))
+	for _, name := range slices.Sorted(maps.Keys(defaults)) {
+		canonical := strings.ToLower(name)
+		index[canonical] = len(merged)
+		merged = append(merged, resolvedAttr{Name: name, Canonical: canonical, Value: defaults[name]})
+	}
+
+	for _, attr := range attrs {
+		if _, ok := skip[attr.Canonical]; ok {
+			continue
+		}
+		i, ok := index[attr.Canonical]
+		if !ok {
+			merged = append(merged, attr)
+			continue
+		}
+		switch attr.Canonical {
+		case "class":
+			merged[i].Value = classNames(merged[i].Value, attr.Value)
+		case "style":
+			merged[i].Value = joinStyles(merged[i].Value, attr.Value)
+		default:
+			merged[i].Value = attr.Value
+		}
+	}
+	return h.forwardAttrs(merged)
+}
+
+// classNames joins class names, dropping empty and repeated ones. Arguments
+// may be strings (split on whitespace), string slices, or maps from class
+// name to a bool saying whether to include it:
+//
+//	class="{{ classNames "btn" (printf "btn-%s" .Props.variant) (dict "active" .Props.active) }}"
+func classNames(values ...any) string {
+	var names []string
+	seen := make(map[string]struct{})
+	add := func(s string) {
+		for _, name := range strings.Fields(s) {
+			if _, dup := seen[name]; !dup {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			add(v)
+		case []string:
+			for _, s := range v {
+				add(s)
+			}
+		case []any:
+			add(classNames(v...))
+		case map[string]bool:
+			for _, name := range slices.Sorted(maps.Keys(v)) {
+				if v[name] {
+					add(name)
+				}
+			}
+		case map[string]any:
+			for _, name := range slices.Sorted(maps.Keys(v)) {
+				if truthy(v[name]) {
+					add(name)
+				}
+			}
+		case bool:
+			// Lets {{ classNames (and .Props.active "active") }} style
+			// conditionals pass false through.
+		default:
+			add(fmt.Sprint(v))
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// joinStyles concatenates inline style declarations so later ones win.
+func joinStyles(values ...any) string {
+	var parts []string
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		if style := strings.Trim(strings.TrimSpace(fmt.Sprint(value)), ";"); style != "" {
+			parts = append(parts, strings.TrimSpace(style))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// dict builds a map from alternating keys and values, for passing several
+// values to a helper at once.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict needs an even number of arguments")
+	}
+	values := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key %v is %T, not a string", pairs[i], pairs[i])
+		}
+		values[key] = pairs[i+1]
+	}
+	return values, nil
+}
+
+// truthy reports whether v counts as true in a template condition.
+func truthy(v any) bool {
+	truth, _ := template.IsTrue(v)
+	return truth
+}
@@ -0,0 +1,79 @@
+package hc
+
+import (
+	"maps"
+	"strings"
+)
+
+// WithStrictMissingKeys makes templates fail on keys that do not exist, so
+// a typo such as {{ .Props.lable }} is an error naming the component, the
+// template position and the key instead of an empty string.
+//
+// Without arguments it applies to every component template, attribute
+// expression and the final template pass. With component names it applies
+// to those component templates only, for tightening a code base one
+// component at a time.
+//
+// Attributes a component declares (with @props or WithAttrRules) but the
+// caller omitted are present as nil, so {{ if .Props.icon }} keeps working
+// for optional ones. Test for anything else with index, as in
+// {{ if index .Props "icon" }}.
+func WithStrictMissingKeys(components ...string) Option {
+	return func(h *HC) {
+		if len(components) == 0 {
+			h.cfg.strictMissingKeys = true
+			return
+		}
+		if h.cfg.strictComponents == nil {
+			h.cfg.strictComponents = make(map[string]struct{})
+		}
+		for _, name := range components {
+			if name = strings.TrimSpace(name); name != "" {
+				h.cfg.strictComponents[strings.ToLower(name)] = struct{}{}
+			}
+		}
+	}
+}
+
+// strictKeys reports whether missing keys are errors in the template of
+// component, or in page-level templates when component is "".
+func (h *HC) strictKeys(component string) bool {
+	if h.cfg.strictMissingKeys {
+		return true
+	}
+	_, ok := h.cfg.strictComponents[strings.ToLower(component)]
+	return ok && component != ""
+}
+
+func missingKeyOption(strict bool) string {
+	if strict {
+		return "missingkey=error"
+	}
+	return "missingkey=zero"
+}
+
+// strictProps returns the props a strict component template sees: props
+// with every declared attribute the caller omitted set to nil.
+func (h *HC) strictProps(component string, props map[string]any) map[string]any {
+	if !h.strictKeys(component) {
+		return props
+	}
+	policy, ok := h.attrPolicy(component)
+	if !ok {
+		return props
+	}
+	var filled map[string]any
+	for name := range policy.allowed {
+		if _, ok := props[name]; ok {
+			continue
+		}
+		if filled == nil {
+			filled = maps.Clone(props)
+		}
+		filled[name] = nil
+	}
+	if filled == nil {
+		return props
+	}
+	return filled
+}
@@ -0,0 +1,126 @@
+package hc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Origin is the source position a component was invoked from. Components
+// emitted by another component's template only know the template file, so
+// their Line and Column are zero.
+type Origin struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (o Origin) String() string {
+	switch {
+	case o.File == "":
+		return ""
+	case o.Line == 0:
+		return o.File
+	case o.Column == 0:
+		return fmt.Sprintf("%s:%d", o.File, o.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", o.File, o.Line, o.Column)
+}
+
+// advance returns the origin of a position at line and col within markup
+// whose first byte sits at o.
+func (o Origin) advance(line, col int) Origin {
+	if o.Line == 0 {
+		return Origin{File: o.File}
+	}
+	if line == 1 {
+		col += o.Column - 1
+	}
+	return Origin{File: o.File, Line: o.Line + line - 1, Column: col}
+}
+
+// ComponentFrame is one active component invocation.
+type ComponentFrame struct {
+	Component string
+	Origin    Origin
+}
+
+func (f ComponentFrame) String() string {
+	if origin := f.Origin.String(); origin != "" {
+		return f.Component + " (" + origin + ")"
+	}
+	return f.Component
+}
+
+// ComponentError reports a failed component invocation along with where it
+// was invoked and the chain of components that were rendering at the time.
+type ComponentError struct {
+	Component string
+	Origin    Origin
+	// Stack lists the active invocations from the outermost one down to the
+	// failing component.
+	Stack []ComponentFrame
+	// Props holds the attributes the failing component received, when the
+	// failure happened after they were resolved.
+	Props map[string]any
+	Err   error
+}
+
+func (e *ComponentError) Error() string {
+	var b strings.Builder
+	if origin := e.Origin.String(); origin != "" {
+		b.WriteString(origin)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	if len(e.Stack) > 1 {
+		b.WriteString(" [stack: ")
+		for i, frame := range e.Stack {
+			if i > 0 {
+				b.WriteString(" > ")
+			}
+			b.WriteString(frame.String())
+		}
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// stackPath renders frames as "A → B → C".
+func stackPath(frames []ComponentFrame) string {
+	names := make([]string, len(frames))
+	for i, frame := range frames {
+		names[i] = frame.Component
+	}
+	return strings.Join(names, " → ")
+}
+
+// wrapComponentError attaches the failing invocation to err unless a nested
+// component already did.
+func wrapComponentError(err error, frame ComponentFrame, stack []ComponentFrame) error {
+	var compErr *ComponentError
+	if errors.As(err, &compErr) {
+		return err
+	}
+	return &ComponentError{
+		Component: frame.Component,
+		Origin:    frame.Origin,
+		Stack:     stack,
+		Err:       err,
+	}
+}
+
+// unclosedTagError is returned by walkMarkup; the renderer turns it into a
+// ComponentError once it knows which file the markup came from.
+type unclosedTagError struct {
+	name   string
+	offset int
+}
+
+func (e *unclosedTagError) Error() string {
+	return "unclosed component tag: " + e.name
+}
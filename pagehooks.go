@@ -0,0 +1,67 @@
+package hc
+
+import (
+	"context"
+	"time"
+)
+
+// PageRender describes one page render to BeforeRender and AfterRender
+// hooks.
+type PageRender struct {
+	Page string
+	// Data is the data the page renders with. BeforeRender hooks may
+	// replace it.
+	Data any
+	// The fields below are set for AfterRender hooks.
+	Duration time.Duration
+	// Components counts the component invocations rendered, including
+	// nested ones.
+	Components int
+	Bytes      int
+	// Output is the rendered page and Err the render error. AfterRender
+	// hooks may replace both: setting Output and clearing Err serves a
+	// fallback page, setting Err vetoes a successful render.
+	Output []byte
+	Err    error
+}
+
+// BeforeRenderHook runs before a page renders. Returning an error skips the
+// render and the remaining BeforeRender hooks; AfterRender hooks still run
+// and see that error in Err.
+type BeforeRenderHook func(ctx context.Context, page *PageRender) error
+
+// AfterRenderHook runs once a page has rendered, before its output is
+// written.
+type AfterRenderHook func(ctx context.Context, page *PageRender)
+
+// WithBeforeRender registers a hook that runs before every page render, for
+// audit logging or access checks.
+func WithBeforeRender(hook BeforeRenderHook) Option {
+	return func(h *HC) {
+		if hook != nil {
+			h.cfg.beforeRenderHooks = append(h.cfg.beforeRenderHooks, hook)
+		}
+	}
+}
+
+// WithAfterRender registers a hook that runs after every page render, with
+// its duration, size, component count and error:
+//
+//	hc.WithAfterRender(func(ctx context.Context, page *hc.PageRender) {
+//		if page.Err != nil {
+//			log.Printf("render %s: %v", page.Page, page.Err)
+//			page.Output, page.Err = fallbackHTML, nil
+//		}
+//	})
+//
+// Hooks run in registration order and each sees the changes of the ones
+// before it. They also run when a BeforeRender hook aborted the render, with
+// its error and no output. So that output can still be replaced, pages are
+// not streamed while AfterRender hooks are registered.
+func WithAfterRender(hook AfterRenderHook) Option {
+	return func(h *HC) {
+		if hook != nil {
+			h.cfg.afterRenderHooks = append(h.cfg.afterRenderHooks, hook)
+		}
+	}
+}
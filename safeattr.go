@@ -0,0 +1,117 @@
+package hc
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// filteredURL replaces URLs with unsafe schemes, matching html/template.
+const filteredURL = "#ZgotmplZ"
+
+// urlAttrs are the attributes whose values are URLs, after html/template.
+var urlAttrs = map[string]struct{}{
+	"action": {}, "archive": {}, "background": {}, "cite": {}, "classid": {},
+	"codebase": {}, "data": {}, "formaction": {}, "href": {}, "icon": {},
+	"longdesc": {}, "manifest": {}, "ping": {}, "poster": {}, "profile": {},
+	"src": {}, "usemap": {}, "xmlns": {}, "xlink:href": {},
+}
+
+// WithEventAttrs allows forwardAttrs to pass through the named event handler
+// attributes, such as "onclick". Other on* attributes are dropped unless
+// their value is a template.JS.
+func WithEventAttrs(names ...string) Option {
+	return func(h *HC) {
+		if h.cfg.eventAttrs == nil {
+			h.cfg.eventAttrs = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			h.cfg.eventAttrs[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// filterAttrValue returns the forwarded value of the attribute with the
+// lower-cased name, or false if the attribute must be dropped.
+func (h *HC) filterAttrValue(name string, value any) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, "on"):
+		if _, ok := value.(template.JS); ok {
+			return fmt.Sprint(value), true
+		}
+		_, allowed := h.cfg.eventAttrs[name]
+		return fmt.Sprint(value), allowed
+	case isURLAttr(name):
+		if _, ok := value.(template.URL); ok {
+			return fmt.Sprint(value), true
+		}
+		if name == "srcset" {
+			return filterSrcset(fmt.Sprint(value)), true
+		}
+		if url := fmt.Sprint(value); safeURL(url) {
+			return url, true
+		}
+		return filteredURL, true
+	}
+	return fmt.Sprint(value), true
+}
+
+// isURLAttr reports whether name holds a URL, using the same heuristics as
+// html/template for names it does not know.
+func isURLAttr(name string) bool {
+	if name == "srcdoc" {
+		return false
+	}
+	if _, ok := urlAttrs[name]; ok || name == "srcset" {
+		return true
+	}
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		name = local
+	}
+	name = strings.TrimPrefix(name, "data-")
+	return strings.Contains(name, "src") || strings.Contains(name, "uri") || strings.Contains(name, "url")
+}
+
+// safeURL reports whether url is relative or uses http, https or mailto.
+// Anything before the first colon that is not followed by a slash counts as
+// a scheme, so obfuscated ones such as "java\tscript:" are rejected too.
+func safeURL(url string) bool {
+	scheme, _, ok := strings.Cut(url, ":")
+	if !ok || strings.Contains(scheme, "/") {
+		return true
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// filterSrcset neutralizes unsafe URLs in a comma-separated srcset list.
+func filterSrcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !safeURL(fields[0]) {
+			candidates[i] = " " + filteredURL
+		}
+	}
+	return strings.TrimSpace(strings.Join(candidates, ","))
+}
+
+// validAttrName reports whether name can be written as an attribute name
+// without changing the meaning of the surrounding tag.
+func validAttrName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r <= ' ', r == 0x7f, r >= 0x80 && r <= 0x9f:
+			return false
+		case strings.ContainsRune("\"'<>/=`", r):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package hc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// spreadAttr merges a map into the props of a component invocation, as in
+// <Button hc-spread=".Parent" label="Save" />. Its value is an expression
+// evaluated like the attributes of built-ins, so it keeps its type.
+const spreadAttr = "hc-spread"
+
+func isSpreadAttr(name string) bool {
+	return strings.EqualFold(name, spreadAttr)
+}
+
+// spreadAttrs evaluates a spread attribute into resolved attributes, in name
+// order.
+func (h *HC) spreadAttrs(state *renderState, raw string, hasValue bool) ([]resolvedAttr, error) {
+	if !hasValue {
+		return nil, errors.New("needs an expression such as \".Parent\"")
+	}
+	value, err := h.evaluateExpr(state, raw)
+	if err != nil {
+		return nil, err
+	}
+	values, err := spreadValues(value)
+	if err != nil {
+		return nil, err
+	}
+	_, resolved := presetAttrs(values)
+	return resolved, nil
+}
+
+func spreadValues(value any) (map[string]any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("expected a map with string keys, got %T", value)
+	}
+	values := make(map[string]any, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		values[iter.Key().String()] = iter.Value().Interface()
+	}
+	return values, nil
+}
+
+// mergeSpread adds the spread attributes to the explicit ones, which take
+// precedence. Spread attributes come first in the result.
+func mergeSpread(props map[string]any, explicit, spread []resolvedAttr) []resolvedAttr {
+	merged := make([]resolvedAttr, 0, len(spread)+len(explicit))
+	for _, attr := range spread {
+		if _, ok := props[attr.Canonical]; ok {
+			continue
+		}
+		props[attr.Canonical] = attr.Value
+		merged = append(merged, attr)
+	}
+	return append(merged, explicit...)
+}